@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/xuri/excelize/v2"
@@ -18,6 +19,11 @@ type ExcelRemarks interface {
 	Remarks() (remark string, row, col int)
 }
 
+// ExcelRowSetter 导入时接收数据在excel中的行号和原始单元格内容，raw 的key为表头名
+type ExcelRowSetter interface {
+	SetExcelRow(row int, raw map[string]string)
+}
+
 type ExcelGatherHeader interface {
 	GatherHeaderRows() int           // 汇总表头占几行，不包括字段行
 	GatherHeader(sheet *Sheet) error // 汇总表头合并单元格，单元格样式需要自己实现
@@ -113,6 +119,7 @@ func (e *Excel) AddSheet(name string) (*Sheet, error) {
 	return &Sheet{
 		Excel:            e.File,
 		SheetName:        name,
+		book:             e,
 		autoCreateHeader: true,
 		row:              0,
 		col:              0,
@@ -132,6 +139,7 @@ func (e *Excel) OpenSheet(sheetName string) (*Sheet, error) {
 		index:            index,
 		Excel:            e.File,
 		SheetName:        sheetName,
+		book:             e,
 		autoCreateHeader: false,
 		row:              0,
 		col:              0,
@@ -147,6 +155,7 @@ func (e *Excel) OpenSheetByMap(sheetName string) (*Sheet, error) {
 				index:            i,
 				Excel:            e.File,
 				SheetName:        name,
+				book:             e,
 				autoCreateHeader: false,
 				row:              0,
 				col:              0,
@@ -178,6 +187,7 @@ func (e *Excel) Response(w http.ResponseWriter) error {
 type Sheet struct {
 	Excel     *excelize.File
 	SheetName string
+	book      *Excel // 所属的Excel，用于读写其他sheet
 
 	header           excelHeaderSlice
 	index            int // sheet index
@@ -185,6 +195,56 @@ type Sheet struct {
 	hasRemarks       bool
 	row              int
 	col              int
+	emptyData        emptyDataOption
+	formulaMode      FormulaMode
+	summary          summaryOption
+	table            tableOption
+	headerRow        int // 字段表头所在行，0表示还没有写表头
+	headerCols       int // 字段表头列数
+	commentAuthor    string
+	expandOrders     map[string]*ExpandOrder
+	expandLocation   *time.Location
+	nullPlaceholder  string
+	defaults         map[string]DefaultFunc
+	rawCellValue     bool
+	rawRows          [][]string // 原始值模式下的单元格原始值
+	date1904         bool
+	afterDecode      func(item interface{}) error
+	duplicateMode    DuplicateMode
+	lookupItems      map[string][]EnumItem
+	lookups          map[lookupRef]*lookupTable
+	readRows         []int // 最近一次 ReadData 每条数据在excel中的行号
+	locale           numberLocale
+	imageLoader      ImageLoader
+	charts           []*SheetChart
+	chartEmpty       ChartEmptyMode
+	dataStart        int // 最近一次 AddData 数据开始行
+	dataEnd          int // 最近一次 AddData 数据结束行，不包括汇总行
+}
+
+// FormulaMode 导入时公式列的读取方式
+type FormulaMode int
+
+const (
+	FormulaValue FormulaMode = iota // 读取公式缓存的计算结果，默认
+	FormulaText                     // 读取公式文本，仅对string字段生效
+)
+
+// EmptyDataMode AddData 数据为空时的处理方式
+type EmptyDataMode int
+
+const (
+	EmptyDataPlaceholder EmptyDataMode = iota // 写入占位文案，默认
+	EmptyDataHeader                           // 根据slice元素类型写入备注和表头
+	EmptyDataNone                             // 什么都不写
+)
+
+type emptyDataOption struct {
+	mode    EmptyDataMode
+	message string
+	hCell   string
+	vCell   string
+	style   int
 }
 
 func (s *Sheet) GetIndex() int {
@@ -195,6 +255,25 @@ func (s *Sheet) SetAutoCreateHeader(on bool) {
 	s.autoCreateHeader = on
 }
 
+// SetFormulaMode 设置导入时公式列读取计算结果还是公式文本
+func (s *Sheet) SetFormulaMode(mode FormulaMode) {
+	s.formulaMode = mode
+}
+
+// SetEmptyData 设置 AddData 数据为空时的处理方式
+func (s *Sheet) SetEmptyData(mode EmptyDataMode) {
+	s.emptyData.mode = mode
+}
+
+// SetEmptyPlaceholder 设置空数据占位文案、合并区域和样式，style为0时不设置样式
+func (s *Sheet) SetEmptyPlaceholder(message, hCell, vCell string, style int) {
+	s.emptyData.mode = EmptyDataPlaceholder
+	s.emptyData.message = message
+	s.emptyData.hCell = hCell
+	s.emptyData.vCell = vCell
+	s.emptyData.style = style
+}
+
 func (s *Sheet) addRow(n ...int) *Sheet {
 	if len(n) == 0 {
 		s.row += 1
@@ -220,11 +299,21 @@ func (s *Sheet) axis(row, col int) (string, error) {
 	return excelize.JoinCellName(_col, row)
 }
 
+// checkNilElem slice中不能有nil元素，否则读取字段会panic
+func checkNilElem(data reflect.Value) error {
+	for k := 0; k < data.Len(); k++ {
+		if !getElem(data.Index(k)).IsValid() {
+			return errors.Errorf("第%d条数据为nil", k+1)
+		}
+	}
+	return nil
+}
+
 func (s *Sheet) fieldIsNil(data reflect.Value, index int) bool {
 	dataValue := getElem(data)
 	for k := 0; k < dataValue.Len(); k++ {
 		v := getElem(dataValue.Index(k))
-		if !v.Field(index).IsNil() {
+		if !isNull(v.Field(index)) {
 			return false
 		}
 	}
@@ -232,39 +321,11 @@ func (s *Sheet) fieldIsNil(data reflect.Value, index int) bool {
 }
 
 // expandHeader
-// fieldIndex 字段index
-// index 表头开始位置
-func (s *Sheet) expandHeader(dataValue reflect.Value, index int, col int) int {
-	keySet := make(map[string]struct{}, 0)
-	keyList := make([]string, 0)
-	// 遍历所有数据，保证扩展字段表头是最完整的
-	for k := 0; k < dataValue.Len(); k++ {
-		v := getElem(dataValue.Index(k))
-		field := v.Field(index)
-		if field.Kind() == reflect.Map {
-			for _, key := range field.MapKeys() {
-				if _, ok := keySet[key.String()]; !ok {
-					keySet[key.String()] = struct{}{}
-					keyList = append(keyList, key.String())
-				}
-			}
-		}
-		if field.Kind() == reflect.Slice {
-		}
-	}
-	sort.Strings(keyList)
-	header := getElem(dataValue.Index(0))
-	for _, v := range keyList {
-		s.header = append(s.header, &excelHeaderField{
-			fieldName:   header.Type().Field(index).Name,
-			headerName:  v,
-			Col:         col,
-			allowEmpty:  false,
-			expand:      false,
-			expandRegex: nil,
-			skip:        false,
-			level:       2,
-		})
+// index 字段index
+// col 表头开始位置
+func (s *Sheet) expandHeader(data reflect.Value, index int, col int, header *excelHeaderField) int {
+	for _, v := range s.collectExpandKeys(data, index, header) {
+		s.header = append(s.header, header.expandChild(v, col))
 		col += 1
 	}
 	return col
@@ -272,8 +333,7 @@ func (s *Sheet) expandHeader(dataValue reflect.Value, index int, col int) int {
 
 // transferHeaders
 // 展开表头
-func (s *Sheet) transferHeaders(data reflect.Value) *Sheet {
-	// var data reflect.Type
+func (s *Sheet) transferHeaders(data reflect.Value) error {
 	col := 1
 	var value reflect.Value
 	if data.Kind() == reflect.Slice {
@@ -281,36 +341,32 @@ func (s *Sheet) transferHeaders(data reflect.Value) *Sheet {
 	} else if data.Kind() == reflect.Struct {
 		value = data
 	} else {
-		panic("表头解析支持 struct | slice")
+		return errors.New("表头解析支持 struct | slice")
+	}
+	if value.Kind() != reflect.Struct {
+		return errors.New("行数据类型必须是struct")
+	}
+	schema, err := getSchema(value.Type())
+	if err != nil {
+		return err
 	}
-	typee := value.Type()
 
-	for i := 0; i < typee.NumField(); i++ {
-		fieldType := value.Field(i)
-		header := ParseExcelHeaderTag(typee.Field(i).Tag.Get("excel"), col)
-		if header.IsSkip() {
-			continue
-		}
-		header.fieldName = typee.Field(i).Name
+	for _, field := range schema.fields {
+		header := field.clone(col)
 		// 字段非nil，设置表头
 		if data.Kind() == reflect.Slice && header.allowEmpty {
-			header.allowEmpty = s.fieldIsNil(data, i)
+			header.allowEmpty = s.fieldIsNil(data, header.index)
 		}
 		// 展开扩展表头
 		if header.expand {
-			if fieldType.Kind() != reflect.Map {
-				panic("expand表头非map[string]类型")
-			}
-			if value.Field(i).Len() > 0 {
-				col = s.expandHeader(data, i, col)
-			}
+			col = s.expandHeader(data, header.index, col, header)
 		} else {
 			col += 1
 		}
 		s.header = append(s.header, header)
 	}
 	s.addRow()
-	return s
+	return nil
 }
 
 func (s Sheet) GetCenterStyle() (int, error) {
@@ -330,39 +386,54 @@ func (s *Sheet) AddHeader(data interface{}) error {
 		dataValue.Len() == 0 {
 		return nil
 	}
+	if err := checkNilElem(dataValue); err != nil {
+		return err
+	}
 
 	headerValue := getElem(dataValue.Index(0))
 
 	switch headerValue.Kind() {
 	case reflect.Struct:
-		s.transferHeaders(dataValue)
-		headerList := s.header
-		sort.Sort(headerList)
+		if err := s.transferHeaders(dataValue); err != nil {
+			return err
+		}
+		return s.writeHeader(headerValue)
+	default:
+		return errors.New("行数据类型必须是struct")
+	}
+}
 
-		gatherHeader, ok := headerValue.Interface().(ExcelGatherHeader)
-		if ok {
-			if err := gatherHeader.GatherHeader(s); err != nil {
-				return err
-			}
-			s.addRow(gatherHeader.GatherHeaderRows())
+// writeHeader 写入汇总表头和字段表头，调用前需要先 transferHeaders 展开表头
+func (s *Sheet) writeHeader(headerValue reflect.Value) error {
+	sort.Sort(s.header)
+
+	gatherHeader, ok := headerValue.Interface().(ExcelGatherHeader)
+	if ok {
+		if err := gatherHeader.GatherHeader(s); err != nil {
+			return err
 		}
+		s.addRow(gatherHeader.GatherHeaderRows())
+	}
+	return s.writeFieldHeader()
+}
 
-		for _, v := range headerList {
-			if v.IsSkip() || v.allowEmpty || v.expand {
-				continue
-			}
-			s.addCol()
-			axis, err := s.axis(s.row, s.col)
-			if err != nil {
-				return err
-			}
-			if err = s.setCellValue(axis, v, v.headerName); err != nil {
-				return err
-			}
+// writeFieldHeader 在当前行写入字段表头
+func (s *Sheet) writeFieldHeader() error {
+	for _, v := range s.header {
+		if v.IsSkip() || v.allowEmpty || v.expand {
+			continue
+		}
+		s.addCol()
+		axis, err := s.axis(s.row, s.col)
+		if err != nil {
+			return err
+		}
+		if err = s.setCellValue(axis, v, v.headerName); err != nil {
+			return err
 		}
-	default:
-		return errors.New("行数据类型必须是struct")
 	}
+	s.headerRow = s.row
+	s.headerCols = s.col
 	return nil
 }
 
@@ -403,6 +474,21 @@ func (s *Sheet) autoAddRemarks(data reflect.Value) error {
 }
 
 func (s *Sheet) setCellValue(axis string, header *excelHeaderField, data interface{}) (err error) {
+	if v, ok := data.(reflect.Value); ok {
+		if isNull(v) {
+			data = s.nullPlaceholder
+		} else if header.hasEnum() {
+			if data, err = enumEncode(v, header); err != nil {
+				return err
+			}
+		} else if header.lookup != nil {
+			if data, err = s.lookupEncode(v, header); err != nil {
+				return err
+			}
+		} else {
+			data = cellValue(v, header)
+		}
+	}
 	if header.font == nil {
 		err = s.Excel.SetCellValue(s.SheetName, axis, data)
 	} else {
@@ -422,6 +508,73 @@ func (s *Sheet) setCellValue(axis string, header *excelHeaderField, data interfa
 	return err
 }
 
+// addEmptyData 数据为空时按 emptyData 设置写入占位文案或表头
+func (s *Sheet) addEmptyData(elemType reflect.Type) error {
+	switch s.emptyData.mode {
+	case EmptyDataNone:
+		return nil
+	case EmptyDataHeader:
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() != reflect.Struct {
+			return errors.New("行数据类型必须是struct")
+		}
+		value := reflect.New(elemType).Elem()
+		if r, ok := value.Interface().(ExcelRemarks); ok && !s.hasRemarks {
+			remarks, height, width := r.Remarks()
+			if err := s.AddRemark(remarks, height, width); err != nil {
+				return err
+			}
+		}
+		if !s.autoCreateHeader {
+			return nil
+		}
+		if err := s.transferHeaders(value); err != nil {
+			return errors.Wrap(err, "创建表头失败")
+		}
+		if err := s.writeHeader(value); err != nil {
+			return errors.Wrap(err, "创建表头失败")
+		}
+		return s.applyTableOption(s.headerRow)
+	default:
+		message, hCell, vCell := s.emptyData.message, s.emptyData.hCell, s.emptyData.vCell
+		if message == "" {
+			message = "没有数据"
+		}
+		if hCell == "" || vCell == "" {
+			hCell, vCell = "A1", "C1"
+		}
+		if err := s.Excel.SetCellValue(s.SheetName, hCell, message); err != nil {
+			return err
+		}
+		if hCell != vCell {
+			if err := s.Excel.MergeCell(s.SheetName, hCell, vCell); err != nil {
+				return err
+			}
+		}
+		if s.emptyData.style > 0 {
+			return s.Excel.SetCellStyle(s.SheetName, hCell, vCell, s.emptyData.style)
+		}
+		return nil
+	}
+}
+
+// resolveFormula 将公式中的 {字段名} 或 {表头名} 替换为当前行对应的单元格，names 来自 getFormulaMap
+func (s *Sheet) resolveFormula(formula string, row int, names excelHeaderMap) string {
+	return formulaFieldRegex.ReplaceAllStringFunc(formula, func(placeholder string) string {
+		h, ok := names[placeholder[1:len(placeholder)-1]]
+		if !ok {
+			return placeholder
+		}
+		axis, err := s.axis(row, h.Col)
+		if err != nil {
+			return placeholder
+		}
+		return axis
+	})
+}
+
 // AddData 遍历slice，导出数据
 func (s *Sheet) AddData(data interface{}) error {
 	dataType := reflect.TypeOf(data)
@@ -431,9 +584,13 @@ func (s *Sheet) AddData(data interface{}) error {
 	}
 
 	if dataValue.Len() == 0 {
-		_ = s.Excel.SetCellValue(s.SheetName, "A1", "没有数据")
-		_ = s.Excel.MergeCell(s.SheetName, "A1", "C1")
-		return nil
+		if err := s.addEmptyData(dataType.Elem()); err != nil {
+			return err
+		}
+		return s.renderCharts()
+	}
+	if err := checkNilElem(dataValue); err != nil {
+		return err
 	}
 
 	if !s.hasRemarks {
@@ -447,7 +604,13 @@ func (s *Sheet) AddData(data interface{}) error {
 			return errors.Wrap(err, "创建表头失败")
 		}
 	}
+	if err := s.writeLookupSheets(); err != nil {
+		return err
+	}
 
+	headerNameMap := s.header.getFieldMap()
+	formulaMap := s.header.getFormulaMap()
+	dataStart := s.row + 1
 	for k := 0; k < dataValue.Len(); k++ {
 		valueStruct := getElem(dataValue.Index(k))
 		if s.autoCreateHeader && valueStruct.Kind() == reflect.Slice && k == 0 {
@@ -457,23 +620,29 @@ func (s *Sheet) AddData(data interface{}) error {
 		switch valueStruct.Kind() {
 		case reflect.Struct:
 			for i := 0; i < valueStruct.NumField(); i++ {
-				value := getElem(valueStruct.Field(i))
-				headerNameMap := s.header.getFieldMap()
 				header, ok := headerNameMap[valueStruct.Type().Field(i).Name]
-				if !ok || header.IsSkip() {
+				if !ok || header.IsSkip() || header.allowEmpty {
 					continue
 				}
-				if header.allowEmpty && s.fieldIsNil(dataValue, i) {
-					continue
-				}
-				if !header.expand {
+				value := getElem(valueStruct.Field(i))
+				if header.formula != "" {
+					axis, _ := s.axis(s.row, header.Col)
+					if err := s.Excel.SetCellFormula(s.SheetName, axis, s.resolveFormula(header.formula, s.row, formulaMap)); err != nil {
+						return err
+					}
+				} else if header.image {
+					axis, _ := s.axis(s.row, header.Col)
+					if err := s.setCellImage(axis, s.row, header, value); err != nil {
+						return err
+					}
+				} else if !header.expand {
 					axis, _ := s.axis(s.row, header.Col)
 					if err := s.setCellValue(axis, header, value); err != nil {
 						return err
 					}
 				} else {
 					for _, key := range value.MapKeys() {
-						if eHeader, ok := headerNameMap[key.String()]; ok {
+						if eHeader, ok := headerNameMap[formatExpandKey(key, header.dateLayout)]; ok {
 							axis, _ := s.axis(s.row, eHeader.Col)
 							if err := s.setCellValue(axis, header, value.MapIndex(key)); err != nil {
 								return err
@@ -481,6 +650,12 @@ func (s *Sheet) AddData(data interface{}) error {
 						}
 					}
 				}
+				if header.comment != "" && !header.expand {
+					axis, _ := s.axis(s.row, header.Col)
+					if err := s.setCellComment(axis, header, valueStruct); err != nil {
+						return err
+					}
+				}
 			}
 		// case reflect.Slice:
 		//	for i := 0; i < valueStruct.Len(); i++ {
@@ -491,7 +666,20 @@ func (s *Sheet) AddData(data interface{}) error {
 			return errors.New("行数据类型必须是struct或slice")
 		}
 	}
-	return nil
+	dataEnd := s.row
+	s.dataStart, s.dataEnd = dataStart, dataEnd
+	if err := s.addLookupValidation(dataStart, dataEnd); err != nil {
+		return err
+	}
+	if s.summary.on {
+		if err := s.addSummaryRow(dataStart, dataEnd); err != nil {
+			return err
+		}
+	}
+	if err := s.applyTableOption(dataEnd); err != nil {
+		return err
+	}
+	return s.renderCharts()
 }
 
 // readHeader 读取表头, 确定表头位置
@@ -506,19 +694,11 @@ func (s *Sheet) readHeader(header []string) {
 			h.isMatch = true
 		} else {
 			for _, v := range expandHeader {
-				if v.expandRegex.MatchString(cell) {
+				if s.matchExpandKey(v, cell) {
 					v.Col = -1
-					s.header = append(s.header, &excelHeaderField{
-						Col:         col + 1,
-						fieldName:   v.fieldName,
-						headerName:  cell,
-						allowEmpty:  false,
-						expand:      false,
-						expandRegex: nil,
-						skip:        false,
-						level:       2,
-						isMatch:     true,
-					})
+					child := v.expandChild(cell, col+1)
+					child.isMatch = true
+					s.header = append(s.header, child)
 				}
 			}
 		}
@@ -535,151 +715,247 @@ func (s *Sheet) ExpandHeaderLen() int {
 	return count
 }
 
-func (s *Sheet) cellToValue(field reflect.Type, cell string, axis string) (reflect.Value, error) {
+// cellToValue 单元格内容转为字段类型，header 可以为nil，locale 为解析数字的分隔符
+func (s *Sheet) cellToValue(field reflect.Type, cell string, axis string, header *excelHeaderField, locale numberLocale) (reflect.Value, error) {
 	cell = strings.TrimSpace(cell)
+	if s.isNullCell(cell) {
+		cell = ""
+	}
 	switch field.Kind() {
 	case reflect.String:
-		return reflect.ValueOf(cell), nil
+		return reflect.ValueOf(cell).Convert(field), nil
 	case reflect.Ptr:
-		v, err := s.cellToValue(field.Elem(), cell, axis)
+		// 空单元格为nil指针
+		if cell == "" {
+			return reflect.Zero(field), nil
+		}
+		v, err := s.cellToValue(field.Elem(), cell, axis, header, locale)
 		if err != nil {
 			return reflect.Value{}, err
 		}
 		x := reflect.New(field.Elem())
 		x.Elem().Set(v)
 		return x, err
-	case reflect.Int8:
-		if cell == "" {
-			return reflect.ValueOf(int8(0)), nil
-		}
-		i, err := strconv.ParseInt(cell, 10, 64)
-		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转int8失败", axis, cell)
-		}
-		return reflect.ValueOf(int8(i)), nil
-	case reflect.Int16:
-		if cell == "" {
-			return reflect.ValueOf(int16(0)), nil
-		}
-		i, err := strconv.ParseInt(cell, 10, 64)
-		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转int16失败", axis, cell)
-		}
-		return reflect.ValueOf(int16(i)), nil
-	case reflect.Int32:
-		if cell == "" {
-			return reflect.ValueOf(int32(0)), nil
+	case reflect.Struct:
+		if field == timeType {
+			return s.parseTime(cell, axis)
 		}
-		i, err := strconv.ParseInt(cell, 10, 64)
-		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转int32失败", axis, cell)
+		if index, ok := nullableValueIndex(field); ok {
+			return s.nullableToValue(field, index, cell, axis, header, locale)
 		}
-		return reflect.ValueOf(int32(i)), nil
-	case reflect.Uint32:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := reflect.New(field).Elem()
 		if cell == "" {
-			return reflect.ValueOf(int32(0)), nil
+			return v, nil
 		}
-		i, err := strconv.ParseInt(cell, 10, 64)
+		i, err := parseInt(cell, locale)
 		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转int32失败", axis, cell)
+			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转%s失败", axis, cell, field.Kind())
 		}
-		return reflect.ValueOf(uint32(i)), nil
-	case reflect.Int:
-		if cell == "" {
-			return reflect.ValueOf(int(0)), nil
-		}
-		i, err := strconv.ParseInt(cell, 10, 64)
-		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转int失败", axis, cell)
+		if v.OverflowInt(i) {
+			return reflect.Value{}, errors.Errorf("%s表格(%s)超出%s范围", axis, cell, field.Kind())
 		}
-		return reflect.ValueOf(int(i)), nil
-	case reflect.Int64:
+		v.SetInt(i)
+		return v, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v := reflect.New(field).Elem()
 		if cell == "" {
-			return reflect.ValueOf(int64(0)), nil
+			return v, nil
 		}
-		i, err := strconv.ParseInt(cell, 10, 64)
+		i, err := parseUint(cell, locale)
 		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转int64失败", axis, cell)
+			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转%s失败", axis, cell, field.Kind())
 		}
-		return reflect.ValueOf(i), nil
-	case reflect.Uint64:
-		if cell == "" {
-			return reflect.ValueOf(int64(0)), nil
-		}
-		i, err := strconv.ParseInt(cell, 10, 64)
-		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转int64失败", axis, cell)
+		if v.OverflowUint(i) {
+			return reflect.Value{}, errors.Errorf("%s表格(%s)超出%s范围", axis, cell, field.Kind())
 		}
-		return reflect.ValueOf(uint64(i)), nil
+		v.SetUint(i)
+		return v, nil
 	case reflect.Bool:
-		lower := strings.ToLower(cell)
-		if lower == "true" || lower == "1" || lower == "t" {
-			return reflect.ValueOf(true), nil
-		} else if lower == "" || lower == "false" || lower == "f" || lower == "0" {
-			return reflect.ValueOf(false), nil
-		} else {
+		b, ok := parseBool(cell, header)
+		if !ok {
 			return reflect.Value{}, errors.Errorf("%s表格(%s)转bool失败", axis, cell)
 		}
-	case reflect.Float32:
+		return reflect.ValueOf(b).Convert(field), nil
+	case reflect.Float32, reflect.Float64:
+		v := reflect.New(field).Elem()
 		if cell == "" {
-			return reflect.ValueOf(float32(0)), nil
+			return v, nil
 		}
-		f, err := strconv.ParseFloat(cell, 64)
+		f, err := parseFloat(cell, locale)
 		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转float32失败", axis, cell)
+			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转%s失败", axis, cell, field.Kind())
 		}
-		return reflect.ValueOf(f), nil
-	case reflect.Float64:
-		if cell == "" {
-			return reflect.ValueOf(float64(0)), nil
-		}
-		f, err := strconv.ParseFloat(cell, 64)
-		if err != nil {
-			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转float64失败", axis, cell)
+		if v.OverflowFloat(f) {
+			return reflect.Value{}, errors.Errorf("%s表格(%s)超出%s范围", axis, cell, field.Kind())
 		}
-		return reflect.ValueOf(f), nil
+		v.SetFloat(f)
+		return v, nil
 	}
 	return reflect.Value{}, errors.Errorf("暂不支持的类型: %s，需要添加一下switch case", field.Kind())
 }
 
-func (s *Sheet) readBody(rows [][]string, data reflect.Value) (interface{}, error) {
+// readBody 解析表格内容，rowNums 为每行在excel中的行号
+// 单元格和行的错误汇总为 ImportErrors 返回
+func (s *Sheet) readBody(rows [][]string, rowNums []int, data reflect.Value, pictures map[string]excelize.Picture) (interface{}, error) {
 	hMap := s.header.getColHeaderMap()
+	var comments map[string]string
+	for _, h := range s.header {
+		if h.isMatch && h.comment != "" {
+			var err error
+			if comments, err = s.readComments(); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	schema, err := getSchema(data.Type())
+	if err != nil {
+		return nil, err
+	}
+	var errs ImportErrors
 	res := reflect.MakeSlice(reflect.SliceOf(reflect.New(data.Type()).Type()), 0, len(rows))
-	for rn, row := range rows {
+	resRows := make([]int, 0, len(rows))
+	for rn, cells := range rows {
+		rowNum := rowNums[rn]
 		itemPtr := reflect.New(data.Type())
-		item := itemPtr.Elem()
-		for col, cell := range row {
-			if h, ok := hMap[col+1]; ok {
-				field := item.FieldByName(h.fieldName)
-				if !field.CanSet() {
-					continue
-				}
-				axis, _ := s.axis(rn+2, col+1)
-
-				switch field.Kind() {
-				case reflect.Map:
-					if value, err := s.cellToValue(field.Type().Elem(), cell, axis); err != nil {
-						return nil, err
-					} else {
-						if field.IsNil() {
-							field.Set(reflect.MakeMap(field.Type()))
-						}
-						field.SetMapIndex(reflect.ValueOf(h.headerName), value)
-					}
-				default:
-					if value, err := s.cellToValue(field.Type(), cell, axis); err != nil {
-						return nil, err
-					} else {
-						field.Set(value)
-					}
-				}
+		row, err := s.beforeRow(itemPtr, cells)
+		if err != nil {
+			if !isSkipRow(err) {
+				errs = append(errs, &CellError{Row: rowNum, Err: err})
 			}
+			continue
+		}
+		rowErrs, err := s.readRow(itemPtr.Elem(), row, rowNum, hMap, comments, pictures)
+		if err != nil {
+			return nil, err
+		}
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		s.setRowMeta(itemPtr, schema, rowNum, cells)
+		if err = s.afterRow(itemPtr); err != nil {
+			if !isSkipRow(err) {
+				errs = append(errs, &CellError{Row: rowNum, Err: err})
+			}
+			continue
 		}
 		res = reflect.Append(res, itemPtr)
+		resRows = append(resRows, rowNum)
+	}
+	res, resRows, dupErrs := s.checkUnique(res, resRows)
+	s.readRows = resRows
+	if errs = append(errs, dupErrs...); len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Row < errs[j].Row })
+		return nil, errs
 	}
 	return res.Interface(), nil
 }
 
+// readRow 解析一行，返回单元格错误，读取excel失败时返回error
+func (s *Sheet) readRow(item reflect.Value, row []string, rowNum int, hMap map[int]*excelHeaderField, comments map[string]string, pictures map[string]excelize.Picture) (ImportErrors, error) {
+	var errs ImportErrors
+	cellErr := func(h *excelHeaderField, col int, err error) {
+		errs = append(errs, &CellError{Row: rowNum, Col: col, Header: h.headerName, Err: err})
+	}
+	row = s.fillDefaults(row)
+	for _, h := range s.header {
+		if !h.isMatch || !h.required || h.image {
+			continue
+		}
+		if h.Col > len(row) || s.isNullCell(row[h.Col-1]) {
+			axis, _ := s.axis(rowNum, h.Col)
+			cellErr(h, h.Col, errors.Errorf("%s表格(%s)不能为空", axis, h.headerName))
+		}
+	}
+	for col, cell := range row {
+		h, ok := hMap[col+1]
+		if !ok {
+			continue
+		}
+		field := item.Field(h.index)
+		if !field.CanSet() || h.image {
+			continue
+		}
+		axis, _ := s.axis(rowNum, col+1)
+		locale := numberLocale{}
+		if h.formula != "" && s.formulaMode == FormulaText && field.Kind() == reflect.String {
+			formula, err := s.Excel.GetCellFormula(s.SheetName, axis)
+			if err != nil {
+				return nil, err
+			}
+			cell = formula
+		} else if raw, ok := s.rawCell(rowNum, col+1, field.Type(), h); ok {
+			cell = raw
+		} else {
+			locale = s.cellLocale(axis)
+		}
+		if s.isNullCell(cell) {
+			cell = ""
+		}
+		cell, err := enumDecode(cell, h, axis)
+		if err == nil {
+			cell, err = s.lookupDecode(cell, h, axis)
+		}
+		if err != nil {
+			cellErr(h, col+1, err)
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.Map:
+			value, err := s.cellToValue(field.Type().Elem(), cell, axis, h, locale)
+			if err != nil {
+				cellErr(h, col+1, err)
+				continue
+			}
+			key, err := s.parseExpandKey(h.headerName, field.Type().Key(), h.dateLayout)
+			if err != nil {
+				cellErr(h, col+1, err)
+				continue
+			}
+			if field.IsNil() {
+				field.Set(reflect.MakeMap(field.Type()))
+			}
+			field.SetMapIndex(key, value)
+		default:
+			value, err := s.cellToValue(field.Type(), cell, axis, h, locale)
+			if err != nil {
+				cellErr(h, col+1, err)
+				continue
+			}
+			field.Set(value)
+		}
+	}
+	if err := s.setMissingDefaults(item); err != nil {
+		errs = append(errs, &CellError{Row: rowNum, Err: err})
+	}
+	for _, h := range s.header {
+		if !h.isMatch || h.comment == "" {
+			continue
+		}
+		axis, _ := s.axis(rowNum, h.Col)
+		if comment, ok := comments[axis]; ok {
+			s.readCellComment(item, h, comment)
+		}
+	}
+	for _, h := range s.header {
+		if !h.isMatch || !h.image {
+			continue
+		}
+		field := item.Field(h.index)
+		if !field.CanSet() {
+			continue
+		}
+		axis, _ := s.axis(rowNum, h.Col)
+		if err := s.readCellImage(field, axis, pictures); err != nil {
+			cellErr(h, h.Col, err)
+		}
+	}
+	return errs, nil
+}
+
 // ReadData 读取表格数据,
 // @return []*struct{}
 func (s *Sheet) ReadData(data interface{}) (interface{}, error) {
@@ -689,7 +965,9 @@ func (s *Sheet) ReadData(data interface{}) (interface{}, error) {
 		return nil, errors.New("data必须是struct类型")
 	}
 
-	s.transferHeaders(dataValue)
+	if err := s.transferHeaders(dataValue); err != nil {
+		return nil, err
+	}
 
 	rows, err := s.Excel.GetRows(s.SheetName)
 	if err != nil {
@@ -700,7 +978,11 @@ func (s *Sheet) ReadData(data interface{}) (interface{}, error) {
 		return nil, errors.New("excel没有数据")
 	}
 
-	rows = s.filterEmpty(rows)
+	if err = s.loadRawRows(); err != nil {
+		return nil, err
+	}
+	lastRow := len(rows)
+	rows, rowNums := s.filterEmpty(rows)
 	// 头部备注
 	start := 0
 	if remarker, ok := data.(ExcelRemarks); ok {
@@ -715,13 +997,39 @@ func (s *Sheet) ReadData(data interface{}) (interface{}, error) {
 		start += gatherHeader.GatherHeaderRows()
 	}
 	s.readHeader(rows[start])
-	return s.readBody(rows[start+1:], dataValue)
+	s.headerRow = rowNums[start]
+	for _, h := range s.header {
+		if h.isMatch && h.lookup != nil {
+			if _, err = s.lookupTable(h.lookup); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, h := range s.header {
+		if h.required && !h.isMatch && !s.hasDefault(h) {
+			return nil, errors.Errorf("缺少必填列：%s", h.headerName)
+		}
+	}
+	rows, rowNums = s.trimSummaryRow(rows[start+1:], rowNums[start+1:])
+	if rows, rowNums, err = s.trimExampleRow(rows, rowNums); err != nil {
+		return nil, err
+	}
+	pictures, err := s.readPictures(lastRow)
+	if err != nil {
+		return nil, err
+	}
+	if rows, rowNums, err = s.addImageRows(rows, rowNums, pictures); err != nil {
+		return nil, err
+	}
+	return s.readBody(rows, rowNums, dataValue, pictures)
 }
 
-func (s *Sheet) filterEmpty(rows [][]string) [][]string {
+// filterEmpty 过滤空行，同时返回保留行在excel中的行号
+func (s *Sheet) filterEmpty(rows [][]string) ([][]string, []int) {
 	res := make([][]string, 0)
+	rowNums := make([]int, 0)
 
-	for _, row := range rows {
+	for i, row := range rows {
 		isEmpty := true
 		for _, col := range row {
 			if len(strings.TrimSpace(col)) > 0 {
@@ -731,8 +1039,9 @@ func (s *Sheet) filterEmpty(rows [][]string) [][]string {
 		}
 		if !isEmpty {
 			res = append(res, row)
+			rowNums = append(rowNums, i+1)
 		}
 	}
 
-	return res
+	return res, rowNums
 }
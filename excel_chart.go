@@ -0,0 +1,146 @@
+package structexcel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// SheetChart 图表声明，字段可以是字段名或表头名，expand字段会展开为所有扩展列
+type SheetChart struct {
+	Type     excelize.ChartType
+	Title    string
+	Category string   // 分类字段，作为X轴
+	Values   []string // 数值字段，每个字段一个系列
+	Cell     string   // 图表左上角位置，为空时放在数据右侧
+	Width    uint
+	Height   uint
+}
+
+// ChartEmptyMode AddData 数据为空时声明的图表的处理方式
+type ChartEmptyMode int
+
+const (
+	ChartEmptySkip   ChartEmptyMode = iota // 不生成图表，默认
+	ChartEmptyHeader                       // 已经写了表头时生成空图表，数据区域为表头下一行，没有表头时不生成
+)
+
+// SetChartEmpty 设置 AddData 数据为空时声明的图表的处理方式
+func (s *Sheet) SetChartEmpty(mode ChartEmptyMode) {
+	s.chartEmpty = mode
+}
+
+// AddChart 声明图表，AddData 写完数据后根据数据区域生成，没有数据时按 SetChartEmpty 处理；已经写过数据时立即生成
+func (s *Sheet) AddChart(chart *SheetChart) error {
+	if s.dataEnd < s.dataStart || s.dataStart == 0 {
+		s.charts = append(s.charts, chart)
+		return nil
+	}
+	return s.renderChart(chart, s.dataStart, s.dataEnd)
+}
+
+// renderCharts 生成声明的图表，每个图表只生成一次
+func (s *Sheet) renderCharts() error {
+	charts := s.charts
+	s.charts = nil
+	start, end := s.dataStart, s.dataEnd
+	if end < start || start == 0 {
+		if s.chartEmpty != ChartEmptyHeader || s.headerRow == 0 {
+			return nil
+		}
+		start, end = s.headerRow+1, s.headerRow+1
+	}
+	for _, chart := range charts {
+		if err := s.renderChart(chart, start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chartHeaders 根据字段名或表头名查找图表引用的列，expand字段返回所有扩展列
+func (s *Sheet) chartHeaders(name string) excelHeaderSlice {
+	res := make(excelHeaderSlice, 0)
+	for _, v := range s.header {
+		if v.level != 1 || (v.fieldName != name && v.headerName != name) {
+			continue
+		}
+		if !v.expand {
+			return append(res, v)
+		}
+		for _, e := range s.header {
+			if e.level == 2 && e.fieldName == v.fieldName {
+				res = append(res, e)
+			}
+		}
+		return res
+	}
+	return res
+}
+
+// chartRange 生成图表引用区域，如：'hello'!$B$2:$B$5
+func (s *Sheet) chartRange(col, start, end int) (string, error) {
+	name, err := excelize.ColumnNumberToName(col)
+	if err != nil {
+		return "", err
+	}
+	sheetName := "'" + strings.ReplaceAll(s.SheetName, "'", "''") + "'"
+	if start == end {
+		return fmt.Sprintf("%s!$%s$%d", sheetName, name, start), nil
+	}
+	return fmt.Sprintf("%s!$%s$%d:$%s$%d", sheetName, name, start, name, end), nil
+}
+
+// renderChart 根据 start 到 end 行的数据生成图表
+func (s *Sheet) renderChart(chart *SheetChart, start, end int) error {
+	category := s.chartHeaders(chart.Category)
+	if len(category) != 1 {
+		return errors.Errorf("图表分类字段不存在：%s", chart.Category)
+	}
+	categories, err := s.chartRange(category[0].Col, start, end)
+	if err != nil {
+		return err
+	}
+
+	series := make([]excelize.ChartSeries, 0)
+	for _, name := range chart.Values {
+		headers := s.chartHeaders(name)
+		if len(headers) == 0 {
+			return errors.Errorf("图表数值字段不存在：%s", name)
+		}
+		for _, h := range headers {
+			seriesName, err := s.chartRange(h.Col, s.headerRow, s.headerRow)
+			if err != nil {
+				return err
+			}
+			values, err := s.chartRange(h.Col, start, end)
+			if err != nil {
+				return err
+			}
+			series = append(series, excelize.ChartSeries{
+				Name:       seriesName,
+				Categories: categories,
+				Values:     values,
+			})
+		}
+	}
+
+	cell := chart.Cell
+	if cell == "" {
+		if cell, err = s.axis(s.headerRow, s.headerCols+2); err != nil {
+			return err
+		}
+	}
+	if err = s.Excel.AddChart(s.SheetName, cell, &excelize.Chart{
+		Type:      chart.Type,
+		Series:    series,
+		Title:     excelize.ChartTitle{Name: chart.Title},
+		Dimension: excelize.ChartDimension{Width: chart.Width, Height: chart.Height},
+		Legend:    excelize.ChartLegend{Position: "bottom"},
+	}); err != nil {
+		return errors.Wrap(err, "创建图表失败")
+	}
+	return nil
+}
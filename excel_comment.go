@@ -0,0 +1,67 @@
+package structexcel
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// SetCommentAuthor 设置导出批注的作者
+func (s *Sheet) SetCommentAuthor(author string) {
+	s.commentAuthor = author
+}
+
+// setCellComment 将 comment tag 指向的字段写入单元格批注，字段为空时不写
+func (s *Sheet) setCellComment(axis string, header *excelHeaderField, valueStruct reflect.Value) error {
+	field := valueStruct.FieldByName(header.comment)
+	if !field.IsValid() {
+		return nil
+	}
+	field = getElem(field)
+	if !field.IsValid() || field.Kind() != reflect.String || field.String() == "" {
+		return nil
+	}
+	return s.Excel.AddComment(s.SheetName, excelize.Comment{
+		Author: s.commentAuthor,
+		Cell:   axis,
+		Runs:   []excelize.RichTextRun{{Text: field.String()}},
+	})
+}
+
+// readComments 读取sheet所有批注，key为单元格坐标
+func (s *Sheet) readComments() (map[string]string, error) {
+	comments, err := s.Excel.GetComments(s.SheetName)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(comments))
+	for _, c := range comments {
+		text := c.Text
+		for _, run := range c.Runs {
+			text += run.Text
+		}
+		// Excel 添加的批注会以 "作者:" 开头
+		if c.Author != "" && strings.HasPrefix(text, c.Author+":") {
+			text = text[len(c.Author)+1:]
+		}
+		res[c.Cell] = strings.TrimSpace(text)
+	}
+	return res, nil
+}
+
+// readCellComment 将单元格批注写入 comment tag 指向的字段
+func (s *Sheet) readCellComment(item reflect.Value, header *excelHeaderField, comment string) {
+	field := item.FieldByName(header.comment)
+	if !field.CanSet() {
+		return
+	}
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(comment)
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.String:
+		v := reflect.New(field.Type().Elem())
+		v.Elem().SetString(comment)
+		field.Set(v)
+	}
+}
@@ -0,0 +1,319 @@
+package structexcel
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// CrossTab 交叉表配置，字段可以是字段名或表头名
+type CrossTab struct {
+	Row    string // 行字段，每个值一行
+	Column string // 列字段，每个值一列，作为动态表头
+	Value  string // 数值字段，Agg为count时可以为空
+	Agg    string // 汇总方式：sum、avg、count、min、max，默认sum
+	Totals bool   // 是否追加合计行和合计列
+}
+
+// aggregator 交叉表单元格汇总
+type aggregator struct {
+	agg   string
+	sum   float64
+	count int
+	min   float64
+	max   float64
+}
+
+func (a *aggregator) add(v float64) {
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	a.sum += v
+	a.count += 1
+}
+
+func (a *aggregator) value() float64 {
+	switch a.agg {
+	case "avg":
+		if a.count == 0 {
+			return 0
+		}
+		return a.sum / float64(a.count)
+	case "count":
+		return float64(a.count)
+	case "min":
+		return a.min
+	case "max":
+		return a.max
+	default:
+		return a.sum
+	}
+}
+
+// toFloat 数值字段转float64
+func toFloat(v reflect.Value) (float64, bool) {
+	v = getElem(v)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// crossTabField 根据字段名或表头名查找字段
+func crossTabField(typee reflect.Type, name string) (reflect.StructField, *excelHeaderField, bool) {
+	schema, err := getSchema(typee)
+	if err != nil {
+		return reflect.StructField{}, nil, false
+	}
+	for _, header := range schema.fields {
+		if header.fieldName == name || header.headerName == name {
+			return typee.Field(header.index), header.clone(0), true
+		}
+	}
+	if field, ok := typee.FieldByName(name); ok {
+		return field, &excelHeaderField{fieldName: field.Name, headerName: field.Name, level: 1}, true
+	}
+	return reflect.StructField{}, nil, false
+}
+
+// AddCrossTab 将扁平数据按行字段、列字段透视为交叉表，列字段的值排序后作为动态表头
+func (s *Sheet) AddCrossTab(data interface{}, opt CrossTab) error {
+	dataValue := reflect.ValueOf(data)
+	if dataValue.Kind() != reflect.Slice {
+		return errors.New("数据必须是slice")
+	}
+	if err := checkNilElem(dataValue); err != nil {
+		return err
+	}
+	if opt.Agg == "" {
+		opt.Agg = "sum"
+	}
+	if _, ok := subtotalFunctions[opt.Agg]; !ok {
+		return errors.Errorf("无效汇总方式：%s", opt.Agg)
+	}
+	elemType := dataValue.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("行数据类型必须是struct")
+	}
+	if err := validateType(elemType); err != nil {
+		return err
+	}
+	rowField, rowHeader, ok := crossTabField(elemType, opt.Row)
+	if !ok {
+		return errors.Errorf("交叉表行字段不存在：%s", opt.Row)
+	}
+	colField, colHeader, ok := crossTabField(elemType, opt.Column)
+	if !ok {
+		return errors.Errorf("交叉表列字段不存在：%s", opt.Column)
+	}
+	var valueField reflect.StructField
+	var valueHeader *excelHeaderField
+	if opt.Value != "" {
+		if valueField, valueHeader, ok = crossTabField(elemType, opt.Value); !ok {
+			return errors.Errorf("交叉表数值字段不存在：%s", opt.Value)
+		}
+	} else if opt.Agg != "count" {
+		return errors.New("交叉表缺少数值字段")
+	}
+
+	rowOrder := s.crossTabOrder(rowHeader, rowField.Type)
+	colOrder := s.crossTabOrder(colHeader, colField.Type)
+	rowKeys := make([]string, 0)
+	rowValues := make(map[string]reflect.Value)
+	colKeys := make([]string, 0)
+	cells := make(map[string]map[string]*aggregator)
+	rowTotals := make(map[string]*aggregator)
+	colTotals := make(map[string]*aggregator)
+	total := &aggregator{agg: opt.Agg}
+	colSet := make(map[string]struct{})
+	for _, key := range colOrder.Keys {
+		if _, ok = colSet[key]; !ok {
+			colSet[key] = struct{}{}
+			colKeys = append(colKeys, key)
+		}
+	}
+	for k := 0; k < dataValue.Len(); k++ {
+		item := getElem(dataValue.Index(k))
+		if !item.IsValid() {
+			continue
+		}
+		rowValue := item.FieldByIndex(rowField.Index)
+		rowKey := formatExpandKey(rowValue, rowHeader.dateLayout)
+		colKey := formatExpandKey(item.FieldByIndex(colField.Index), colHeader.dateLayout)
+		v := 0.0
+		if opt.Value != "" {
+			if v, ok = toFloat(item.FieldByIndex(valueField.Index)); !ok && opt.Agg != "count" {
+				return errors.Errorf("交叉表数值字段必须是数字：%s", opt.Value)
+			}
+		}
+		if _, ok = cells[rowKey]; !ok {
+			cells[rowKey] = make(map[string]*aggregator)
+			rowTotals[rowKey] = &aggregator{agg: opt.Agg}
+			rowValues[rowKey] = rowValue
+			rowKeys = append(rowKeys, rowKey)
+		}
+		if _, ok = colSet[colKey]; !ok {
+			colSet[colKey] = struct{}{}
+			colKeys = append(colKeys, colKey)
+		}
+		if _, ok = colTotals[colKey]; !ok {
+			colTotals[colKey] = &aggregator{agg: opt.Agg}
+		}
+		if _, ok = cells[rowKey][colKey]; !ok {
+			cells[rowKey][colKey] = &aggregator{agg: opt.Agg}
+		}
+		cells[rowKey][colKey].add(v)
+		rowTotals[rowKey].add(v)
+		colTotals[colKey].add(v)
+		total.add(v)
+	}
+	sortExpandKeys(rowKeys, rowOrder)
+	sortExpandKeys(colKeys, colOrder)
+
+	if !s.hasRemarks {
+		if err := s.autoAddRemarks(dataValue); err != nil {
+			return err
+		}
+	}
+
+	// 表头：行字段 + 列字段的值 + 合计，列字段作为扩展表头方便图表引用
+	rowHeader.Col = 1
+	colHeader.Col = 2
+	colHeader.expand = true
+	s.header = excelHeaderSlice{rowHeader, colHeader}
+	for i, key := range colKeys {
+		s.header = append(s.header, colHeader.expandChild(key, i+2))
+	}
+	totalCol := len(colKeys) + 2
+	if opt.Totals {
+		s.header = append(s.header, &excelHeaderField{
+			Col:        totalCol,
+			headerName: s.summaryLabel(),
+			level:      2,
+		})
+	}
+	s.addRow()
+	if err := s.writeHeader(reflect.New(elemType).Elem()); err != nil {
+		return errors.Wrap(err, "创建表头失败")
+	}
+
+	cellHeader := &excelHeaderField{}
+	if valueHeader != nil {
+		cellHeader.font = valueHeader.font
+	}
+	writeValue := func(col int, a *aggregator) error {
+		axis, err := s.axis(s.row, col)
+		if err != nil {
+			return err
+		}
+		v := a.value()
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return s.setCellValue(axis, cellHeader, int64(v))
+		}
+		return s.setCellValue(axis, cellHeader, v)
+	}
+	// 合计使用公式，修改单元格后自动重算；平均值无法由各单元格的平均值得出，直接写入汇总结果
+	fn := crossTabFunctions[opt.Agg]
+	writeTotal := func(col int, a *aggregator, hCell, vCell string) error {
+		if fn == "" {
+			return writeValue(col, a)
+		}
+		axis, err := s.axis(s.row, col)
+		if err != nil {
+			return err
+		}
+		return s.Excel.SetCellFormula(s.SheetName, axis, fmt.Sprintf("%s(%s:%s)", fn, hCell, vCell))
+	}
+
+	s.dataStart = s.row + 1
+	for _, rowKey := range rowKeys {
+		s.addRow()
+		axis, _ := s.axis(s.row, 1)
+		if err := s.setCellValue(axis, rowHeader, rowValues[rowKey]); err != nil {
+			return err
+		}
+		for i, colKey := range colKeys {
+			if a, ok := cells[rowKey][colKey]; ok {
+				if err := writeValue(i+2, a); err != nil {
+					return err
+				}
+			}
+		}
+		if opt.Totals {
+			hCell, _ := s.axis(s.row, 2)
+			vCell, _ := s.axis(s.row, totalCol-1)
+			if err := writeTotal(totalCol, rowTotals[rowKey], hCell, vCell); err != nil {
+				return err
+			}
+		}
+	}
+	s.dataEnd = s.row
+
+	if opt.Totals && len(rowKeys) > 0 {
+		s.addRow()
+		axis, _ := s.axis(s.row, 1)
+		if err := s.Excel.SetCellValue(s.SheetName, axis, s.summaryLabel()); err != nil {
+			return err
+		}
+		for i, colKey := range colKeys {
+			if a, ok := colTotals[colKey]; ok {
+				hCell, _ := s.axis(s.dataStart, i+2)
+				vCell, _ := s.axis(s.dataEnd, i+2)
+				if err := writeTotal(i+2, a, hCell, vCell); err != nil {
+					return err
+				}
+			}
+		}
+		hCell, _ := s.axis(s.dataStart, totalCol)
+		vCell, _ := s.axis(s.dataEnd, totalCol)
+		if err := writeTotal(totalCol, total, hCell, vCell); err != nil {
+			return err
+		}
+		if err := s.setSummaryStyle(s.row, s.headerCols); err != nil {
+			return err
+		}
+	}
+	if err := s.applyTableOption(s.dataEnd); err != nil {
+		return err
+	}
+	return s.renderCharts()
+}
+
+// crossTabFunctions 合计行、合计列使用的公式，count 的合计是各单元格计数之和
+var crossTabFunctions = map[string]string{
+	"sum":   "SUM",
+	"count": "SUM",
+	"min":   "MIN",
+	"max":   "MAX",
+}
+
+// crossTabOrder 交叉表行列的排序，没有设置 SetExpandOrder 时数字按自然序、时间按日期序
+func (s *Sheet) crossTabOrder(header *excelHeaderField, typee reflect.Type) *ExpandOrder {
+	order := s.getExpandOrder(header)
+	if order.Less != nil || order.Sort != ExpandSortLexical {
+		return order
+	}
+	for typee.Kind() == reflect.Ptr {
+		typee = typee.Elem()
+	}
+	res := *order
+	if typee == timeType {
+		res.Sort = ExpandSortDate
+	} else if _, ok := toFloat(reflect.Zero(typee)); ok {
+		res.Sort = ExpandSortNatural
+	}
+	return &res
+}
@@ -0,0 +1,100 @@
+package structexcel
+
+import (
+	"reflect"
+)
+
+// DefaultFunc 导入时计算默认值，返回值按单元格内容转换，如：当前导入用户
+type DefaultFunc func() string
+
+// SetDefault 设置导入时字段的默认值，单元格为空或者缺少该列时使用，优先于tag default
+// field 可以是字段名或者表头名
+func (s *Sheet) SetDefault(field string, fn DefaultFunc) {
+	if s.defaults == nil {
+		s.defaults = make(map[string]DefaultFunc)
+	}
+	s.defaults[field] = fn
+}
+
+func (s *Sheet) defaultFunc(header *excelHeaderField) (DefaultFunc, bool) {
+	if header.level != 1 || header.expand || header.image {
+		return nil, false
+	}
+	if fn, ok := s.defaults[header.fieldName]; ok {
+		return fn, true
+	}
+	fn, ok := s.defaults[header.headerName]
+	return fn, ok
+}
+
+func (s *Sheet) hasDefault(header *excelHeaderField) bool {
+	if _, ok := s.defaultFunc(header); ok {
+		return true
+	}
+	return header.defaultVal != "" && header.level == 1 && !header.expand && !header.image
+}
+
+// defaultCell 字段的默认值，没有默认值返回false
+func (s *Sheet) defaultCell(header *excelHeaderField) (string, bool) {
+	if fn, ok := s.defaultFunc(header); ok {
+		return fn(), true
+	}
+	if s.hasDefault(header) {
+		return header.defaultVal, true
+	}
+	return "", false
+}
+
+// fillDefaults 空单元格填充默认值，不修改原来的row
+func (s *Sheet) fillDefaults(row []string) []string {
+	res := row
+	copied := false
+	for _, h := range s.header {
+		if !h.isMatch || (h.Col <= len(row) && !s.isNullCell(row[h.Col-1])) {
+			continue
+		}
+		cell, ok := s.defaultCell(h)
+		if !ok {
+			continue
+		}
+		if !copied || len(res) < h.Col {
+			n := len(res)
+			if n < h.Col {
+				n = h.Col
+			}
+			x := make([]string, n)
+			copy(x, res)
+			res, copied = x, true
+		}
+		res[h.Col-1] = cell
+	}
+	return res
+}
+
+// setMissingDefaults 表格中缺少的列设置默认值
+func (s *Sheet) setMissingDefaults(item reflect.Value) error {
+	for _, h := range s.header {
+		if h.isMatch {
+			continue
+		}
+		cell, ok := s.defaultCell(h)
+		if !ok {
+			continue
+		}
+		field := item.Field(h.index)
+		if !field.CanSet() {
+			continue
+		}
+		axis := h.headerName
+		cell, err := enumDecode(cell, h, axis)
+		if err != nil {
+			return err
+		}
+		value, err := s.cellToValue(field.Type(), cell, axis, h, numberLocale{})
+		if err != nil {
+			return err
+		}
+		field.Set(value)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package structexcel
+
+import (
+	"encoding/xml"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxRelationships 解析 .rels 文件
+type xlsxRelationships struct {
+	Relationship []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxDrawing 解析 drawing 中单元格锚定的图片
+type xlsxDrawing struct {
+	TwoCellAnchor []struct {
+		From *struct {
+			Col int `xml:"col"`
+			Row int `xml:"row"`
+		} `xml:"from"`
+		Pic *struct {
+			NvPicPr struct {
+				CNvPr struct {
+					Descr string `xml:"descr,attr"`
+				} `xml:"cNvPr"`
+			} `xml:"nvPicPr"`
+			BlipFill struct {
+				Blip struct {
+					Embed string `xml:"embed,attr"`
+				} `xml:"blip"`
+			} `xml:"blipFill"`
+		} `xml:"pic"`
+	} `xml:"twoCellAnchor"`
+}
+
+// readPackageXML 读取文件包中的xml，不存在返回false
+func (s *Sheet) readPackageXML(name string, v interface{}) bool {
+	content, ok := s.Excel.Pkg.Load(name)
+	if !ok {
+		return false
+	}
+	data, ok := content.([]byte)
+	return ok && xml.Unmarshal(data, v) == nil
+}
+
+// relationshipTarget 将 rels 中的相对路径转为文件包中的路径
+func relationshipTarget(dir, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(dir, target)
+}
+
+// sheetPictures 解析一次sheet的drawing，返回所有单元格图片，key为单元格坐标
+// excelize.GetPictures 每次调用都会重新解析整个drawing，逐个单元格读取是 O(行数×图片数)
+// drawing 已经被excelize加载（可能有未保存的修改）或者不在文件包中时返回false，需要逐个单元格读取
+func (s *Sheet) sheetPictures() (map[string]excelize.Picture, bool) {
+	if s.Excel.WorkBook == nil || s.Excel.WorkBook.Sheets.Sheet == nil {
+		return nil, false
+	}
+	rID := ""
+	for _, sheet := range s.Excel.WorkBook.Sheets.Sheet {
+		if strings.EqualFold(sheet.Name, s.SheetName) {
+			rID = sheet.ID
+		}
+	}
+	workbookRels := xlsxRelationships{}
+	if rID == "" || !s.readPackageXML("xl/_rels/workbook.xml.rels", &workbookRels) {
+		return nil, false
+	}
+	sheetPath := ""
+	for _, rel := range workbookRels.Relationship {
+		if rel.ID == rID {
+			sheetPath = relationshipTarget("xl", rel.Target)
+		}
+	}
+	sheetRels := xlsxRelationships{}
+	if sheetPath == "" || !s.readPackageXML(path.Join(path.Dir(sheetPath), "_rels", path.Base(sheetPath)+".rels"), &sheetRels) {
+		return nil, false
+	}
+	drawingPath := ""
+	for _, rel := range sheetRels.Relationship {
+		if strings.HasSuffix(rel.Type, "/drawing") {
+			drawingPath = relationshipTarget(path.Dir(sheetPath), rel.Target)
+		}
+	}
+	if drawingPath == "" {
+		return nil, false
+	}
+	if _, loaded := s.Excel.Drawings.Load(drawingPath); loaded {
+		return nil, false
+	}
+	drawing, drawingRels := xlsxDrawing{}, xlsxRelationships{}
+	if !s.readPackageXML(drawingPath, &drawing) ||
+		!s.readPackageXML(path.Join(path.Dir(drawingPath), "_rels", path.Base(drawingPath)+".rels"), &drawingRels) {
+		return nil, false
+	}
+	media := make(map[string]string, len(drawingRels.Relationship))
+	for _, rel := range drawingRels.Relationship {
+		media[rel.ID] = relationshipTarget(path.Dir(drawingPath), rel.Target)
+	}
+
+	res := make(map[string]excelize.Picture)
+	for _, anchor := range drawing.TwoCellAnchor {
+		if anchor.From == nil || anchor.Pic == nil {
+			continue
+		}
+		axis, err := excelize.CoordinatesToCellName(anchor.From.Col+1, anchor.From.Row+1)
+		if err != nil {
+			continue
+		}
+		if _, ok := res[axis]; ok {
+			continue
+		}
+		target, ok := media[anchor.Pic.BlipFill.Blip.Embed]
+		if !ok {
+			continue
+		}
+		if file, ok := s.Excel.Pkg.Load(target); ok {
+			res[axis] = excelize.Picture{
+				Extension: filepath.Ext(target),
+				File:      file.([]byte),
+				Format:    &excelize.GraphicOptions{AltText: anchor.Pic.NvPicPr.CNvPr.Descr},
+			}
+		}
+	}
+	return res, true
+}
@@ -0,0 +1,109 @@
+package structexcel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// EnumItem 枚举值和展示文案
+type EnumItem struct {
+	Code  string
+	Label string
+}
+
+// enumRegistry map[string][]EnumItem
+var enumRegistry sync.Map
+
+// RegisterEnum 注册命名枚举，字段通过tag enum:name 引用，如：
+//
+//	RegisterEnum("status", []EnumItem{{"1", "待审核"}, {"2", "已通过"}, {"3", "已拒绝"}})
+func RegisterEnum(name string, items []EnumItem) {
+	enumRegistry.Store(name, items)
+}
+
+// parseEnum 解析tag：enum:1=待审核|2=已通过，不包含 "=" 时为注册的枚举名称
+func parseEnum(spec string) ([]EnumItem, string, error) {
+	if !strings.Contains(spec, "=") {
+		if spec == "" {
+			return nil, "", errors.New("格式：enum:1=待审核|2=已通过 或 enum:注册名称")
+		}
+		return nil, spec, nil
+	}
+	items := make([]EnumItem, 0)
+	for _, pair := range strings.Split(spec, "|") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, "", errors.New("格式：enum:1=待审核|2=已通过")
+		}
+		items = append(items, EnumItem{Code: kv[0], Label: kv[1]})
+	}
+	return items, "", nil
+}
+
+// enumItems 获取字段的枚举，没有设置时返回nil
+func (e *excelHeaderField) enumItems() ([]EnumItem, error) {
+	if e.enumName == "" {
+		return e.enum, nil
+	}
+	v, ok := enumRegistry.Load(e.enumName)
+	if !ok {
+		return nil, errors.Errorf("枚举%s没有注册", e.enumName)
+	}
+	return v.([]EnumItem), nil
+}
+
+func (e *excelHeaderField) hasEnum() bool {
+	return e.enumName != "" || len(e.enum) > 0
+}
+
+// enumLabels 枚举的所有展示文案
+func enumLabels(items []EnumItem) []string {
+	labels := make([]string, 0, len(items))
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+// enumEncode 导出时将字段值转为展示文案，没有对应文案时原样输出
+func enumEncode(v reflect.Value, header *excelHeaderField) (interface{}, error) {
+	value := cellValue(v, header)
+	items, err := header.enumItems()
+	if err != nil {
+		return nil, err
+	}
+	code := fmt.Sprint(value)
+	for _, item := range items {
+		if item.Code == code {
+			return item.Label, nil
+		}
+	}
+	return value, nil
+}
+
+// enumDecode 导入时将展示文案转回枚举值，也接受枚举值本身
+func enumDecode(cell string, header *excelHeaderField, axis string) (string, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" || !header.hasEnum() {
+		return cell, nil
+	}
+	items, err := header.enumItems()
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		if item.Label == cell {
+			return item.Code, nil
+		}
+	}
+	for _, item := range items {
+		if item.Code == cell {
+			return item.Code, nil
+		}
+	}
+	return "", errors.Errorf("%s表格(%s)无效，可选值：%s", axis, cell, strings.Join(enumLabels(items), "、"))
+}
@@ -0,0 +1,54 @@
+package structexcel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CellError 导入时某一行或者某个单元格的错误
+type CellError struct {
+	Sheet  string // sheet名称，ReadInto 汇总多个sheet的错误时设置
+	Row    int    // excel中的行号
+	Col    int    // 列号，整行的错误为0
+	Header string // 表头名，整行的错误为空
+	Err    error
+}
+
+func (e *CellError) Error() string {
+	msg := e.Err.Error()
+	if e.Col <= 0 {
+		msg = fmt.Sprintf("第%d行：%s", e.Row, msg)
+	}
+	if e.Sheet != "" {
+		msg = e.Sheet + "：" + msg
+	}
+	return msg
+}
+
+func (e *CellError) Cause() error { return e.Err }
+
+func (e *CellError) Unwrap() error { return e.Err }
+
+// ImportErrors ReadData 汇总的所有行错误，按行号顺序
+type ImportErrors []*CellError
+
+func (e ImportErrors) Error() string {
+	msg := make([]string, 0, len(e))
+	for _, v := range e {
+		msg = append(msg, v.Error())
+	}
+	return strings.Join(msg, "\n")
+}
+
+// Rows 有错误的行号
+func (e ImportErrors) Rows() []int {
+	rows := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, v := range e {
+		if !seen[v.Row] {
+			seen[v.Row] = true
+			rows = append(rows, v.Row)
+		}
+	}
+	return rows
+}
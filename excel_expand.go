@@ -0,0 +1,257 @@
+package structexcel
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExpandSort expand扩展列排序方式
+type ExpandSort int
+
+const (
+	ExpandSortLexical ExpandSort = iota // 字典序，默认
+	ExpandSortNatural                   // 自然序，数字部分按数值比较：2月 < 10月
+	ExpandSortDate                      // 日期序，支持 2022-1-2、2022/01/02、2022年1月 等格式
+	ExpandSortKeys                      // 按 Keys 顺序，不在 Keys 中的按字典序排在后面
+)
+
+// ExpandOrder expand扩展列顺序
+type ExpandOrder struct {
+	Sort ExpandSort
+	Keys []string               // 固定表头，即使没有数据也会生成这些列
+	Less func(a, b string) bool // 自定义排序，设置后忽略 Sort
+}
+
+var expandSortNames = map[string]ExpandSort{
+	"lexical": ExpandSortLexical,
+	"natural": ExpandSortNatural,
+	"date":    ExpandSortDate,
+	"keys":    ExpandSortKeys,
+}
+
+// SetExpandOrder 设置expand字段扩展列的顺序和固定表头，field 为字段名或表头名，优先级高于tag
+func (s *Sheet) SetExpandOrder(field string, order ExpandOrder) {
+	if s.expandOrders == nil {
+		s.expandOrders = make(map[string]*ExpandOrder)
+	}
+	s.expandOrders[field] = &order
+}
+
+// SetExpandLocation 设置导入时 time.Time 类型的扩展key的时区，默认 UTC
+// 导出时表头按key自身的时区格式化，导出和导入的时区需要一致
+func (s *Sheet) SetExpandLocation(loc *time.Location) {
+	s.expandLocation = loc
+}
+
+// getExpandOrder 获取扩展字段排序，Sheet设置优先于tag
+func (s *Sheet) getExpandOrder(header *excelHeaderField) *ExpandOrder {
+	if order, ok := s.expandOrders[header.fieldName]; ok {
+		return order
+	}
+	if order, ok := s.expandOrders[header.headerName]; ok {
+		return order
+	}
+	if header.expandOrder != nil {
+		return header.expandOrder
+	}
+	return &ExpandOrder{}
+}
+
+// collectExpandKeys 收集所有数据的扩展表头并排序，data 可以是 slice 或 struct
+func (s *Sheet) collectExpandKeys(data reflect.Value, index int, header *excelHeaderField) []string {
+	order := s.getExpandOrder(header)
+	keySet := make(map[string]struct{}, 0)
+	keyList := make([]string, 0)
+	add := func(key string) {
+		if _, ok := keySet[key]; !ok {
+			keySet[key] = struct{}{}
+			keyList = append(keyList, key)
+		}
+	}
+	for _, key := range order.Keys {
+		add(key)
+	}
+	// 遍历所有数据，保证扩展字段表头是最完整的
+	items := []reflect.Value{data}
+	if data.Kind() == reflect.Slice {
+		items = make([]reflect.Value, 0, data.Len())
+		for k := 0; k < data.Len(); k++ {
+			items = append(items, getElem(data.Index(k)))
+		}
+	}
+	for _, v := range items {
+		field := v.Field(index)
+		if field.Kind() == reflect.Map {
+			for _, key := range field.MapKeys() {
+				add(formatExpandKey(key, header.dateLayout))
+			}
+		}
+	}
+	sortExpandKeys(keyList, order)
+	return keyList
+}
+
+// sortExpandKeys 按 ExpandOrder 排序
+func sortExpandKeys(keys []string, order *ExpandOrder) {
+	if order.Less != nil {
+		sort.SliceStable(keys, func(i, j int) bool { return order.Less(keys[i], keys[j]) })
+		return
+	}
+	switch order.Sort {
+	case ExpandSortNatural:
+		sort.SliceStable(keys, func(i, j int) bool { return naturalLess(keys[i], keys[j]) })
+	case ExpandSortDate:
+		sort.SliceStable(keys, func(i, j int) bool { return dateLess(keys[i], keys[j]) })
+	case ExpandSortKeys:
+		rank := make(map[string]int, len(order.Keys))
+		for i, key := range order.Keys {
+			rank[key] = i
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			ri, iok := rank[keys[i]]
+			rj, jok := rank[keys[j]]
+			if iok && jok {
+				return ri < rj
+			}
+			if iok != jok {
+				return iok
+			}
+			return keys[i] < keys[j]
+		})
+	default:
+		sort.Strings(keys)
+	}
+}
+
+// naturalLess 自然序比较，连续数字按数值比较
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ca, na := splitNatural(a)
+		cb, nb := splitNatural(b)
+		if ca != cb {
+			da, errA := strconv.ParseFloat(ca, 64)
+			db, errB := strconv.ParseFloat(cb, 64)
+			if errA == nil && errB == nil && da != db {
+				return da < db
+			}
+			return ca < cb
+		}
+		a, b = na, nb
+	}
+	return len(a) < len(b)
+}
+
+// splitNatural 拆出开头连续的数字或非数字部分
+func splitNatural(s string) (string, string) {
+	isDigit := s[0] >= '0' && s[0] <= '9'
+	i := 1
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9') == isDigit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+var expandDateLayouts = []string{
+	"2006-01-02 15:04:05", "2006-01-02", "2006-1-2", "2006/01/02", "2006/1/2",
+	"2006-01", "2006-1", "2006/01", "2006/1", "2006年1月2日", "2006年1月", "01-02", "1-2", "1月2日",
+}
+
+func parseExpandDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range expandDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dateLess 日期序比较，不能解析为日期的排在后面按字典序
+func dateLess(a, b string) bool {
+	ta, aok := parseExpandDate(a)
+	tb, bok := parseExpandDate(b)
+	if aok && bok {
+		return ta.Before(tb)
+	}
+	if aok != bok {
+		return aok
+	}
+	return a < b
+}
+
+// matchExpandKey 导入时判断表头是否属于扩展字段
+func (s *Sheet) matchExpandKey(header *excelHeaderField, cell string) bool {
+	if header.expandRegex != nil && header.expandRegex.MatchString(cell) {
+		return true
+	}
+	for _, key := range s.getExpandOrder(header).Keys {
+		if key == cell {
+			return true
+		}
+	}
+	return false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// formatExpandKey map key转为表头，time.Time 按 expand 的日期格式输出
+func formatExpandKey(key reflect.Value, layout string) string {
+	key = getElem(key)
+	if !key.IsValid() {
+		return ""
+	}
+	if key.Type() == timeType {
+		if layout == "" {
+			layout = "2006-01-02"
+		}
+		return key.Interface().(time.Time).Format(layout)
+	}
+	switch key.Kind() {
+	case reflect.String:
+		return key.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(key.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(key.Float(), 'f', -1, 64)
+	}
+	if stringer, ok := key.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprint(key.Interface())
+}
+
+// parseExpandKey 导入时将表头解析为map key，time.Time 按 SetExpandLocation 的时区解析
+func (s *Sheet) parseExpandKey(name string, keyType reflect.Type, layout string) (reflect.Value, error) {
+	if keyType == timeType {
+		loc := s.expandLocation
+		if loc == nil {
+			loc = time.UTC
+		}
+		layouts := expandDateLayouts
+		if layout != "" {
+			layouts = append([]string{layout}, layouts...)
+		}
+		for _, l := range layouts {
+			if t, err := time.ParseInLocation(l, strings.TrimSpace(name), loc); err == nil {
+				return reflect.ValueOf(t), nil
+			}
+		}
+		return reflect.Value{}, errors.Errorf("表头(%s)转日期失败", name)
+	}
+	if keyType.Kind() == reflect.String {
+		return reflect.ValueOf(name).Convert(keyType), nil
+	}
+	value, err := s.cellToValue(keyType, name, name, nil, numberLocale{})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return value.Convert(keyType), nil
+}
@@ -0,0 +1,59 @@
+package structexcel
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSkipRow 导入钩子返回该错误时跳过当前行，不记为错误
+var ErrSkipRow = errors.New("跳过该行")
+
+// ExcelBeforeRow 导入时解析每一行之前调用，raw 按列号对应单元格文本，可以直接修改
+type ExcelBeforeRow interface {
+	BeforeRow(raw []string) error
+}
+
+// ExcelRowValidator 导入时每一行解析完成后调用，用于跨字段校验，如：结束日期必须晚于开始日期
+type ExcelRowValidator interface {
+	Validate() error
+}
+
+// SetAfterDecode 设置导入时每一行解析完成后的处理函数，item 为struct指针，在 Validate 之前调用
+func (s *Sheet) SetAfterDecode(fn func(item interface{}) error) {
+	s.afterDecode = fn
+}
+
+func isSkipRow(err error) bool {
+	return errors.Cause(err) == ErrSkipRow
+}
+
+// beforeRow 调用 BeforeRow，传入补齐到表头宽度的副本
+func (s *Sheet) beforeRow(itemPtr reflect.Value, row []string) ([]string, error) {
+	hook, ok := itemPtr.Interface().(ExcelBeforeRow)
+	if !ok {
+		return row, nil
+	}
+	n := len(row)
+	for _, h := range s.header {
+		if h.isMatch && h.Col > n {
+			n = h.Col
+		}
+	}
+	raw := make([]string, n)
+	copy(raw, row)
+	return raw, hook.BeforeRow(raw)
+}
+
+// afterRow 调用 SetAfterDecode 设置的函数和 Validate
+func (s *Sheet) afterRow(itemPtr reflect.Value) error {
+	if s.afterDecode != nil {
+		if err := s.afterDecode(itemPtr.Interface()); err != nil {
+			return err
+		}
+	}
+	if v, ok := itemPtr.Interface().(ExcelRowValidator); ok {
+		return v.Validate()
+	}
+	return nil
+}
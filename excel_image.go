@@ -0,0 +1,245 @@
+package structexcel
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// defaultImageHeight 图片默认高度，单位像素
+const defaultImageHeight = 60
+
+// maxImageSize HTTPImageLoader 下载图片的大小限制
+const maxImageSize = 10 << 20
+
+// ImageLoader 加载string图片字段的函数，src 为字段内容，如：url、文件路径、对象存储key
+type ImageLoader func(src string) ([]byte, error)
+
+// SetImageLoader 设置string图片字段的加载函数；默认只支持 data uri，
+// url、本地文件等来源需要显式设置，避免行数据触发任意网络请求和文件读取，如：
+//
+//	sheet.SetImageLoader(HTTPImageLoader(5 * time.Second))
+func (s *Sheet) SetImageLoader(loader ImageLoader) {
+	s.imageLoader = loader
+}
+
+// HTTPImageLoader 通过 http(s) 下载图片，timeout 为每张图片的超时时间，图片不能超过10MB
+func HTTPImageLoader(timeout time.Duration) ImageLoader {
+	client := &http.Client{Timeout: timeout}
+	return func(src string) ([]byte, error) {
+		if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+			return nil, errors.Errorf("仅支持http(s)图片：%s", src)
+		}
+		resp, err := client.Get(src)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, errors.Errorf("图片下载(%s)失败了: %d", src, resp.StatusCode)
+		}
+		raw, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) > maxImageSize {
+			return nil, errors.Errorf("图片(%s)超过10MB", src)
+		}
+		return raw, nil
+	}
+}
+
+// setCellImage 将 []byte、data uri 或 ImageLoader 加载的字段写入单元格图片，按 image tag 高度缩放并调整行高
+func (s *Sheet) setCellImage(axis string, row int, header *excelHeaderField, value reflect.Value) error {
+	raw, err := s.loadImage(value)
+	if err != nil {
+		return errors.Wrapf(err, "%s图片读取失败", axis)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return errors.Wrapf(err, "%s图片解析失败", axis)
+	}
+	height := header.imageHeight
+	if height <= 0 {
+		height = defaultImageHeight
+	}
+	scale := 1.0
+	if config.Height > 0 {
+		scale = height / float64(config.Height)
+	}
+	if err = s.Excel.AddPictureFromBytes(s.SheetName, axis, &excelize.Picture{
+		Extension: "." + format,
+		File:      raw,
+		Format: &excelize.GraphicOptions{
+			ScaleX:          scale,
+			ScaleY:          scale,
+			LockAspectRatio: true,
+			OffsetX:         2,
+			OffsetY:         2,
+		},
+	}); err != nil {
+		return err
+	}
+	// 像素转磅
+	rowHeight := (height + 4) * 0.75
+	if current, err := s.Excel.GetRowHeight(s.SheetName, row); err == nil && current >= rowHeight {
+		return nil
+	}
+	return s.Excel.SetRowHeight(s.SheetName, row, rowHeight)
+}
+
+// loadImage 读取图片内容，string 支持 data uri，其他内容通过 ImageLoader 加载
+func (s *Sheet) loadImage(value reflect.Value) ([]byte, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, errors.New("image字段必须是[]byte或string")
+		}
+		return value.Bytes(), nil
+	case reflect.String:
+		src := strings.TrimSpace(value.String())
+		if src == "" {
+			return nil, nil
+		}
+		if strings.HasPrefix(src, "data:") {
+			i := strings.Index(src, ";base64,")
+			if i == -1 {
+				return nil, errors.New("仅支持base64编码的data uri")
+			}
+			return base64.StdEncoding.DecodeString(src[i+8:])
+		}
+		if s.imageLoader == nil {
+			return nil, errors.Errorf("图片(%s)需要通过 SetImageLoader 设置加载函数", src)
+		}
+		return s.imageLoader(src)
+	default:
+		return nil, errors.New("image字段必须是[]byte或string")
+	}
+}
+
+// readCellImage 将单元格的图片写入字段，[]byte 字段写入图片内容，string 字段写入 data uri
+func (s *Sheet) readCellImage(field reflect.Value, axis string, pictures map[string]excelize.Picture) error {
+	pic, ok := pictures[axis]
+	if !ok {
+		return nil
+	}
+	target := field
+	if field.Kind() == reflect.Ptr {
+		target = reflect.New(field.Type().Elem()).Elem()
+	}
+	switch target.Kind() {
+	case reflect.Slice:
+		if target.Type().Elem().Kind() != reflect.Uint8 {
+			return errors.Errorf("%s image字段必须是[]byte或string", axis)
+		}
+		target.Set(reflect.ValueOf(pic.File).Convert(target.Type()))
+	case reflect.String:
+		contentType := mime.TypeByExtension(pic.Extension)
+		if contentType == "" {
+			contentType = http.DetectContentType(pic.File)
+		}
+		target.SetString("data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(pic.File))
+	default:
+		return errors.Errorf("%s image字段必须是[]byte或string", axis)
+	}
+	if field.Kind() == reflect.Ptr {
+		field.Set(target.Addr())
+	}
+	return nil
+}
+
+// readPictures 读取表头下方图片列的所有图片，key为单元格坐标，每次 ReadData 只读取一次
+func (s *Sheet) readPictures(lastRow int) (map[string]excelize.Picture, error) {
+	imageHeaders := make(map[int]*excelHeaderField)
+	for _, h := range s.header {
+		if h.isMatch && h.image {
+			imageHeaders[h.Col] = h
+		}
+	}
+	if len(imageHeaders) == 0 {
+		return nil, nil
+	}
+	res := make(map[string]excelize.Picture)
+	if pictures, ok := s.sheetPictures(); ok {
+		for axis, pic := range pictures {
+			col, row, _ := excelize.CellNameToCoordinates(axis)
+			if _, ok = imageHeaders[col]; ok && row > s.headerRow {
+				res[axis] = pic
+			}
+		}
+		return res, nil
+	}
+	// 逐个单元格读取：从表头下一行读取到 lastRow（GetRows 的行数），之后继续读取到没有图片的行为止
+	for row := s.headerRow + 1; ; row++ {
+		found := false
+		for _, h := range imageHeaders {
+			axis, _ := s.axis(row, h.Col)
+			pictures, err := s.Excel.GetPictures(s.SheetName, axis)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s图片读取失败", axis)
+			}
+			if len(pictures) > 0 {
+				res[axis] = pictures[0]
+				found = true
+			}
+		}
+		if !found && row > lastRow {
+			break
+		}
+	}
+	return res, nil
+}
+
+// addImageRows 只有图片没有文字的行会被 filterEmpty 过滤，根据 readPictures 的结果补回这些行
+func (s *Sheet) addImageRows(rows [][]string, rowNums []int, pictures map[string]excelize.Picture) ([][]string, []int, error) {
+	if len(pictures) == 0 {
+		return rows, rowNums, nil
+	}
+	exist := make(map[int]bool, len(rowNums))
+	for _, row := range rowNums {
+		exist[row] = true
+	}
+	for axis := range pictures {
+		_, row, err := excelize.CellNameToCoordinates(axis)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exist[row] {
+			exist[row] = true
+			rows = append(rows, []string{})
+			rowNums = append(rowNums, row)
+		}
+	}
+	// 按行号排序
+	index := make([]int, len(rows))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool { return rowNums[index[i]] < rowNums[index[j]] })
+	sortedRows := make([][]string, len(rows))
+	sortedNums := make([]int, len(rows))
+	for i, k := range index {
+		sortedRows[i], sortedNums[i] = rows[k], rowNums[k]
+	}
+	return sortedRows, sortedNums, nil
+}
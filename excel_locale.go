@@ -0,0 +1,125 @@
+package structexcel
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTruthy defaultFalsy 导入时总是支持的bool词汇，Excel原生的bool单元格读取为 TRUE/FALSE
+var (
+	defaultTruthy = []string{"true", "t", "1"}
+	defaultFalsy  = []string{"false", "f", "0"}
+)
+
+var (
+	boolMu     sync.RWMutex
+	boolTruthy []string // SetBoolVocabulary 设置的词汇
+	boolFalsy  []string
+)
+
+// SetBoolVocabulary 设置全局bool词汇，如：SetBoolVocabulary([]string{"是", "Y", "√"}, []string{"否", "N", "×"})
+// 导入时不区分大小写匹配，在默认的 true/t/1、false/f/0 之外增加这些词；导出时bool写成第一个词
+// 传空恢复默认，导出写成Excel的bool值；字段可以通过tag bool:是|否 单独设置
+func SetBoolVocabulary(truthy, falsy []string) {
+	boolMu.Lock()
+	defer boolMu.Unlock()
+	if len(truthy) == 0 || len(falsy) == 0 {
+		boolTruthy, boolFalsy = nil, nil
+		return
+	}
+	boolTruthy = truthy
+	boolFalsy = falsy
+}
+
+// parseBool 按字段tag、全局词汇和默认词汇解析bool，空单元格为false
+func parseBool(cell string, header *excelHeaderField) (bool, bool) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return false, true
+	}
+	if header != nil && header.boolTrue != "" {
+		if strings.EqualFold(cell, header.boolTrue) {
+			return true, true
+		}
+		if strings.EqualFold(cell, header.boolFalse) {
+			return false, true
+		}
+	}
+	boolMu.RLock()
+	defer boolMu.RUnlock()
+	for _, words := range [][]string{boolTruthy, defaultTruthy} {
+		for _, v := range words {
+			if strings.EqualFold(cell, v) {
+				return true, true
+			}
+		}
+	}
+	for _, words := range [][]string{boolFalsy, defaultFalsy} {
+		for _, v := range words {
+			if strings.EqualFold(cell, v) {
+				return false, true
+			}
+		}
+	}
+	return false, false
+}
+
+// formatBool 导出bool，设置了tag或全局词汇时写成文本
+func formatBool(b bool, header *excelHeaderField) interface{} {
+	if header != nil && header.boolTrue != "" {
+		if b {
+			return header.boolTrue
+		}
+		return header.boolFalse
+	}
+	boolMu.RLock()
+	defer boolMu.RUnlock()
+	if len(boolTruthy) == 0 {
+		return b
+	}
+	if b {
+		return boolTruthy[0]
+	}
+	return boolFalsy[0]
+}
+
+// cellValue 字段值转为写入单元格的值，自定义类型转为基础类型，nil写空
+func cellValue(v reflect.Value, header *excelHeaderField) interface{} {
+	v = getElem(v)
+	if !v.IsValid() {
+		return ""
+	}
+	if index, ok := nullableValueIndex(v.Type()); ok {
+		if !v.FieldByName("Valid").Bool() {
+			return ""
+		}
+		return cellValue(v.Field(index), header)
+	}
+	if v.Type() == timeType && v.Interface().(time.Time).IsZero() {
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return formatBool(v.Bool(), header)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32:
+		// 避免float32转float64后出现 0.10000000149011612
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(v.Float(), 'g', -1, 32), 64)
+		return f
+	case reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,216 @@
+package structexcel
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+var lookupRegex = regexp.MustCompile(`^(.+)!([A-Za-z]+):([A-Za-z]+)$`)
+
+// lookupRef tag lookup:部门表!A:B，A列为字段值，B列为展示文案
+type lookupRef struct {
+	sheet string
+	key   int
+	label int
+}
+
+// lookupTable 查找表，codes 为值到文案，labels 为文案到值
+type lookupTable struct {
+	codes  map[string]string
+	labels map[string]string
+}
+
+func parseLookup(spec string) (*lookupRef, error) {
+	m := lookupRegex.FindStringSubmatch(spec)
+	if m == nil {
+		return nil, errors.New("格式：lookup:部门表!A:B")
+	}
+	key, err := excelize.ColumnNameToNumber(m[2])
+	if err != nil {
+		return nil, err
+	}
+	label, err := excelize.ColumnNameToNumber(m[3])
+	if err != nil {
+		return nil, err
+	}
+	return &lookupRef{sheet: m[1], key: key, label: label}, nil
+}
+
+// labelRange 展示文案所在列，用于下拉框
+func (r *lookupRef) labelRange() string {
+	col, _ := excelize.ColumnNumberToName(r.label)
+	return fmt.Sprintf("'%s'!$%s:$%s", strings.ReplaceAll(r.sheet, "'", "''"), col, col)
+}
+
+// SetLookup 设置查找表数据，导出时如果excel中没有该sheet会自动生成，Code 写入 lookup 的第一列，Label 写入第二列
+//
+//	sheet.SetLookup("部门表", []EnumItem{{"D01", "研发部"}, {"D02", "市场部"}})
+func (s *Sheet) SetLookup(sheetName string, items []EnumItem) {
+	if s.lookupItems == nil {
+		s.lookupItems = make(map[string][]EnumItem)
+	}
+	s.lookupItems[sheetName] = items
+	s.lookups = nil
+}
+
+// lookupTable 读取查找表，优先使用 SetLookup 设置的数据，其次读取excel中对应的sheet
+func (s *Sheet) lookupTable(ref *lookupRef) (*lookupTable, error) {
+	if table, ok := s.lookups[*ref]; ok {
+		return table, nil
+	}
+	table := &lookupTable{codes: make(map[string]string), labels: make(map[string]string)}
+	if items, ok := s.lookupItems[ref.sheet]; ok {
+		for _, item := range items {
+			table.add(item.Code, item.Label)
+		}
+	} else {
+		if s.book == nil {
+			return nil, errors.Errorf("查找表%s需要通过Excel打开sheet", ref.sheet)
+		}
+		sheet, err := s.book.OpenSheet(ref.sheet)
+		if err != nil {
+			return nil, errors.Wrapf(err, "查找表%s", ref.sheet)
+		}
+		rows, err := sheet.Excel.GetRows(sheet.SheetName)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if ref.key > len(row) || ref.label > len(row) {
+				continue
+			}
+			table.add(strings.TrimSpace(row[ref.key-1]), strings.TrimSpace(row[ref.label-1]))
+		}
+	}
+	if s.lookups == nil {
+		s.lookups = make(map[lookupRef]*lookupTable)
+	}
+	s.lookups[*ref] = table
+	return table, nil
+}
+
+func (t *lookupTable) add(code, label string) {
+	if code == "" || label == "" {
+		return
+	}
+	if _, ok := t.codes[code]; !ok {
+		t.codes[code] = label
+	}
+	if _, ok := t.labels[label]; !ok {
+		t.labels[label] = code
+	}
+}
+
+// lookupEncode 导出时将字段值转为查找表中的文案，没有对应文案时原样输出
+func (s *Sheet) lookupEncode(v reflect.Value, header *excelHeaderField) (interface{}, error) {
+	value := cellValue(v, header)
+	if _, ok := s.lookupItems[header.lookup.sheet]; !ok && !s.hasSheet(header.lookup.sheet) {
+		return value, nil
+	}
+	table, err := s.lookupTable(header.lookup)
+	if err != nil {
+		return nil, err
+	}
+	if label, ok := table.codes[fmt.Sprint(value)]; ok {
+		return label, nil
+	}
+	return value, nil
+}
+
+// lookupDecode 导入时将文案转回查找表中的值，也接受值本身
+func (s *Sheet) lookupDecode(cell string, header *excelHeaderField, axis string) (string, error) {
+	if cell == "" || header.lookup == nil {
+		return cell, nil
+	}
+	table, err := s.lookupTable(header.lookup)
+	if err != nil {
+		return "", err
+	}
+	if code, ok := table.labels[cell]; ok {
+		return code, nil
+	}
+	if _, ok := table.codes[cell]; ok {
+		return cell, nil
+	}
+	return "", errors.Errorf("%s表格(%s)在%s中不存在", axis, cell, header.lookup.sheet)
+}
+
+func (s *Sheet) hasSheet(name string) bool {
+	index, err := s.Excel.GetSheetIndex(name)
+	return err == nil && index != -1
+}
+
+// writeLookupSheets 导出时生成 SetLookup 设置的查找表sheet，excel中已经存在的sheet不覆盖
+func (s *Sheet) writeLookupSheets() error {
+	for _, h := range s.header {
+		if h.lookup == nil || s.hasSheet(h.lookup.sheet) {
+			continue
+		}
+		items, ok := s.lookupItems[h.lookup.sheet]
+		if !ok {
+			continue
+		}
+		if s.book == nil {
+			return errors.Errorf("查找表%s需要通过Excel创建sheet", h.lookup.sheet)
+		}
+		// AddSheet 会切换当前sheet，生成后恢复
+		active := s.Excel.GetSheetName(s.Excel.GetActiveSheetIndex())
+		sheet, err := s.book.AddSheet(h.lookup.sheet)
+		if err != nil {
+			return err
+		}
+		if index, err := s.Excel.GetSheetIndex(active); err == nil && index != -1 {
+			s.Excel.SetActiveSheet(index)
+		}
+		for i, item := range items {
+			key, _ := sheet.axis(i+1, h.lookup.key)
+			label, _ := sheet.axis(i+1, h.lookup.label)
+			if err = s.Excel.SetCellValue(sheet.SheetName, key, item.Code); err != nil {
+				return err
+			}
+			if err = s.Excel.SetCellValue(sheet.SheetName, label, item.Label); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookupValidation 引用查找表文案列的下拉框，查找表sheet不存在时返回nil
+func (s *Sheet) lookupValidation(header *excelHeaderField) *excelize.DataValidation {
+	if !s.hasSheet(header.lookup.sheet) {
+		return nil
+	}
+	dv := excelize.NewDataValidation(!header.required)
+	dv.SetSqrefDropList(header.lookup.labelRange())
+	dv.SetError(excelize.DataValidationErrorStyleStop, header.headerName, "请从"+header.lookup.sheet+"中选择")
+	return dv
+}
+
+// addLookupValidation lookup列添加下拉框
+func (s *Sheet) addLookupValidation(start, end int) error {
+	if end < start {
+		return nil
+	}
+	for _, h := range s.header {
+		if h.lookup == nil || h.level != 1 || h.expand {
+			continue
+		}
+		dv := s.lookupValidation(h)
+		if dv == nil {
+			continue
+		}
+		first, _ := s.axis(start, h.Col)
+		last, _ := s.axis(end, h.Col)
+		dv.SetSqref(first + ":" + last)
+		if err := s.Excel.AddDataValidation(s.SheetName, dv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package structexcel
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SetNullPlaceholder 设置nil指针和无效 sql.Null* 导出的文案，如："-"，导入时该文案也按空处理
+func (s *Sheet) SetNullPlaceholder(placeholder string) {
+	s.nullPlaceholder = placeholder
+}
+
+// nullableValueIndex 判断是否是 sql.Null* 或 struct{ V T; Valid bool } 形式的可空类型，返回值字段index
+func nullableValueIndex(t reflect.Type) (int, bool) {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 {
+		return -1, false
+	}
+	valid, ok := t.FieldByName("Valid")
+	if !ok || valid.Type.Kind() != reflect.Bool || len(valid.Index) != 1 {
+		return -1, false
+	}
+	index := 1 - valid.Index[0]
+	if t.Field(index).PkgPath != "" {
+		return -1, false
+	}
+	return index, true
+}
+
+// isNull nil指针、nil interface 或 Valid 为false的可空类型
+func isNull(v reflect.Value) bool {
+	v = getElem(v)
+	if !v.IsValid() {
+		return true
+	}
+	if _, ok := nullableValueIndex(v.Type()); ok {
+		return !v.FieldByName("Valid").Bool()
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isNullCell 空单元格或null占位文案
+func (s *Sheet) isNullCell(cell string) bool {
+	cell = strings.TrimSpace(cell)
+	return cell == "" || (s.nullPlaceholder != "" && cell == s.nullPlaceholder)
+}
+
+// nullableToValue 导入可空类型，空单元格 Valid 为false
+func (s *Sheet) nullableToValue(field reflect.Type, index int, cell, axis string, header *excelHeaderField, locale numberLocale) (reflect.Value, error) {
+	v := reflect.New(field).Elem()
+	if s.isNullCell(cell) {
+		return v, nil
+	}
+	inner, err := s.cellToValue(field.Field(index).Type, cell, axis, header, locale)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v.Field(index).Set(inner)
+	v.FieldByName("Valid").SetBool(true)
+	return v, nil
+}
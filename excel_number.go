@@ -0,0 +1,159 @@
+package structexcel
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// numberLocale 数字的小数点和千分位分隔符，零值为默认的 "." 和 ","
+type numberLocale struct {
+	decimal   string
+	thousands string
+}
+
+// SetNumberLocale 设置导入时数字的小数点和千分位分隔符，如德语：SetNumberLocale(",", ".")
+// 默认小数点为 "."，千分位为 ","；只对文本单元格生效，数字单元格和原始值总是使用 "."
+func (s *Sheet) SetNumberLocale(decimal, thousands string) {
+	s.locale = numberLocale{decimal: decimal, thousands: thousands}
+}
+
+// cellLocale 单元格解析数字使用的分隔符，只有文本单元格使用 SetNumberLocale 的设置
+func (s *Sheet) cellLocale(axis string) numberLocale {
+	if s.locale == (numberLocale{}) {
+		return numberLocale{}
+	}
+	cellType, err := s.Excel.GetCellType(s.SheetName, axis)
+	if err != nil {
+		return numberLocale{}
+	}
+	if cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString || cellType == excelize.CellTypeFormula {
+		return s.locale
+	}
+	return numberLocale{}
+}
+
+// normalizeNumber 去掉千分位和空格，小数点统一为 "."，返回是否是百分数，如：1,234 -> 1234，12.5% -> 12.5 true
+// 千分位后面必须是3位数字，如：1,5、1,2,3 报错
+func normalizeNumber(cell string, locale numberLocale) (string, bool, error) {
+	cell = strings.TrimSpace(cell)
+	percent := false
+	if strings.HasSuffix(cell, "%") || strings.HasSuffix(cell, "％") {
+		percent = true
+		cell = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(cell, "%"), "％"))
+	}
+	thousands, decimal := ",", "."
+	if locale.thousands != "" {
+		thousands = locale.thousands
+	}
+	if locale.decimal != "" {
+		decimal = locale.decimal
+	}
+	if strings.Contains(cell, thousands) {
+		if !validThousands(cell, thousands, decimal) {
+			return "", false, errors.Errorf("千分位格式错误：%s", cell)
+		}
+		cell = strings.ReplaceAll(cell, thousands, "")
+	}
+	if decimal != "." {
+		cell = strings.ReplaceAll(cell, decimal, ".")
+	}
+	cell = strings.ReplaceAll(cell, " ", "")
+	cell = strings.ReplaceAll(cell, "\u00a0", "")
+	return cell, percent, nil
+}
+
+// validThousands 千分位只能出现在整数部分，第一组1~3位数字，后面每组都是3位数字
+func validThousands(cell, thousands, decimal string) bool {
+	integer, fraction := cell, ""
+	if i := strings.Index(cell, decimal); i >= 0 {
+		integer, fraction = cell[:i], cell[i:]
+	} else if i := strings.IndexAny(cell, "eE"); i >= 0 {
+		integer, fraction = cell[:i], cell[i:]
+	}
+	if strings.Contains(fraction, thousands) {
+		return false
+	}
+	groups := strings.Split(strings.TrimLeft(integer, "+-"), thousands)
+	for i, group := range groups {
+		if (i == 0 && (len(group) == 0 || len(group) > 3)) || (i > 0 && len(group) != 3) {
+			return false
+		}
+		for _, c := range group {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseFloat 支持千分位、科学计数法和百分数
+func parseFloat(cell string, locale numberLocale) (float64, error) {
+	num, percent, err := normalizeNumber(cell, locale)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	if percent {
+		f /= 100
+	}
+	return f, nil
+}
+
+// parseInt 支持 1,234、1.0、1e3 等写法，小数部分不为0时报错
+func parseInt(cell string, locale numberLocale) (int64, error) {
+	num, percent, err := normalizeNumber(cell, locale)
+	if err != nil {
+		return 0, err
+	}
+	if !percent {
+		if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	f, err := parseFloat(cell, locale)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) {
+		return 0, errors.New("不是整数")
+	}
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, errors.New("超出int64范围")
+	}
+	return int64(f), nil
+}
+
+// parseUint 同 parseInt，负数报错
+func parseUint(cell string, locale numberLocale) (uint64, error) {
+	num, percent, err := normalizeNumber(cell, locale)
+	if err != nil {
+		return 0, err
+	}
+	if !percent {
+		if i, err := strconv.ParseUint(num, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	f, err := parseFloat(cell, locale)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 {
+		return 0, errors.New("不能是负数")
+	}
+	if f != math.Trunc(f) {
+		return 0, errors.New("不是整数")
+	}
+	if f >= math.MaxUint64 {
+		return 0, errors.New("超出uint64范围")
+	}
+	return uint64(f), nil
+}
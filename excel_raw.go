@@ -0,0 +1,105 @@
+package structexcel
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// timeLayouts 导入 time.Time 字段支持的文本格式
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+	"1/2/06 15:04",
+	"01-02-06",
+	"2006年1月2日",
+	"2006-01",
+	time.RFC3339,
+}
+
+// SetRawCellValue 导入时数字、日期字段读取单元格原始值，不受单元格数字格式影响
+// 如：12.35% 读取为 0.1235，1,234.00 读取为 1234，日期读取为序列号后转为 time.Time；string字段仍然读取格式化后的文本
+func (s *Sheet) SetRawCellValue(on bool) {
+	s.rawCellValue = on
+}
+
+// loadRawRows 原始值模式下读取整个sheet的原始值，按行号索引
+func (s *Sheet) loadRawRows() error {
+	s.rawRows = nil
+	if props, err := s.Excel.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		s.date1904 = *props.Date1904
+	}
+	if !s.rawCellValue {
+		return nil
+	}
+	rows, err := s.Excel.GetRows(s.SheetName, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return err
+	}
+	s.rawRows = rows
+	return nil
+}
+
+// rawCell 数字、日期字段返回单元格原始值，文本类型的单元格和其他字段返回false
+func (s *Sheet) rawCell(row, col int, field reflect.Type, header *excelHeaderField) (string, bool) {
+	if s.rawRows == nil || header.hasEnum() {
+		return "", false
+	}
+	if field.Kind() == reflect.Map {
+		field = field.Elem()
+	}
+	field = derefType(field)
+	if index, ok := nullableValueIndex(field); ok {
+		field = derefType(field.Field(index).Type)
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+	default:
+		if field != timeType {
+			return "", false
+		}
+	}
+	axis, err := s.axis(row, col)
+	if err != nil {
+		return "", false
+	}
+	if cellType, err := s.Excel.GetCellType(s.SheetName, axis); err != nil ||
+		cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString || cellType == excelize.CellTypeBool {
+		return "", false
+	}
+	if row > len(s.rawRows) || col > len(s.rawRows[row-1]) {
+		return "", true
+	}
+	return s.rawRows[row-1][col-1], true
+}
+
+// parseTime 单元格转为 time.Time，数字按Excel日期序列号转换，空单元格为零值
+func (s *Sheet) parseTime(cell, axis string) (reflect.Value, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return reflect.ValueOf(time.Time{}), nil
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil {
+		t, err := excelize.ExcelDateToTime(f, s.date1904)
+		if err != nil {
+			return reflect.Value{}, errors.Wrapf(err, "%s表格(%s)转日期失败", axis, cell)
+		}
+		return reflect.ValueOf(t), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, cell, time.Local); err == nil {
+			return reflect.ValueOf(t), nil
+		}
+	}
+	return reflect.Value{}, errors.Errorf("%s表格(%s)转日期失败", axis, cell)
+}
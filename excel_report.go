@@ -0,0 +1,167 @@
+package structexcel
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrorReportHeader 错误报告追加的错误信息列表头
+var ErrorReportHeader = "错误信息"
+
+// ErrorReport 根据 ReadData 返回的 ImportErrors 生成标注后的excel副本，原excel不修改
+// 出错的单元格标红并添加批注，每行最后追加一列错误信息，返回的 Excel 可以直接 Response
+// errs 可以是 ReadInto 汇总的错误，只标注当前sheet的错误
+func (s *Sheet) ErrorReport(errs ImportErrors, filename string) (*Excel, error) {
+	sheetErrs := make(ImportErrors, 0, len(errs))
+	for _, e := range errs {
+		if e.Sheet == "" || e.Sheet == s.SheetName {
+			c := *e
+			c.Sheet = ""
+			sheetErrs = append(sheetErrs, &c)
+		}
+	}
+	errs = sheetErrs
+	if len(errs) == 0 {
+		return nil, errors.New("没有导入错误")
+	}
+	buf, err := s.Excel.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	report, err := OpenReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	report.Filename = filename
+	f := report.File
+
+	rows, err := f.GetRows(s.SheetName)
+	if err != nil {
+		return nil, err
+	}
+	msgCol := 0
+	for _, row := range rows {
+		if len(row) > msgCol {
+			msgCol = len(row)
+		}
+	}
+	msgCol += 1
+
+	fillStyle, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	msgStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Color: "FF0000"},
+		Alignment: &excelize.Alignment{WrapText: true, Vertical: "center"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	comments, err := f.GetComments(s.SheetName)
+	if err != nil {
+		return nil, err
+	}
+	existComments := make(map[string]excelize.Comment, len(comments))
+	for _, c := range comments {
+		existComments[c.Cell] = c
+	}
+
+	messages := make(map[int][]string)
+	cellMessages := make(map[string][]string)
+	cells := make([]string, 0)
+	for _, e := range errs {
+		messages[e.Row] = append(messages[e.Row], e.Error())
+		if e.Col <= 0 {
+			continue
+		}
+		axis, err := s.axis(e.Row, e.Col)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := cellMessages[axis]; !ok {
+			cells = append(cells, axis)
+		}
+		cellMessages[axis] = append(cellMessages[axis], e.Err.Error())
+	}
+	fills := make(map[int]int)
+	for _, axis := range cells {
+		style, err := f.GetCellStyle(s.SheetName, axis)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := fills[style]; !ok {
+			fills[style] = mergeFillStyle(f, style, fillStyle)
+		}
+		if err = f.SetCellStyle(s.SheetName, axis, axis, fills[style]); err != nil {
+			return nil, err
+		}
+		// 已有批注时追加错误信息，不重复添加批注
+		comment := excelize.Comment{Author: s.commentAuthor, Cell: axis}
+		if exist, ok := existComments[axis]; ok {
+			if err = f.DeleteComment(s.SheetName, axis); err != nil {
+				return nil, err
+			}
+			comment.Author = exist.Author
+			comment.Runs = append(comment.Runs, exist.Runs...)
+			if len(exist.Runs) == 0 && exist.Text != "" {
+				comment.Runs = append(comment.Runs, excelize.RichTextRun{Text: exist.Text})
+			}
+			comment.Runs = append(comment.Runs, excelize.RichTextRun{Text: "\n"})
+		}
+		comment.Runs = append(comment.Runs, excelize.RichTextRun{
+			Text: strings.Join(cellMessages[axis], "\n"),
+			Font: &excelize.Font{Color: "FF0000"},
+		})
+		if err = f.AddComment(s.SheetName, comment); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.headerRow > 0 {
+		axis, _ := s.axis(s.headerRow, msgCol)
+		if err = f.SetCellValue(s.SheetName, axis, ErrorReportHeader); err != nil {
+			return nil, err
+		}
+		if err = f.SetCellStyle(s.SheetName, axis, axis, msgStyle); err != nil {
+			return nil, err
+		}
+	}
+	for _, row := range errs.Rows() {
+		axis, err := s.axis(row, msgCol)
+		if err != nil {
+			return nil, err
+		}
+		if err = f.SetCellValue(s.SheetName, axis, strings.Join(messages[row], "；")); err != nil {
+			return nil, err
+		}
+		if err = f.SetCellStyle(s.SheetName, axis, axis, msgStyle); err != nil {
+			return nil, err
+		}
+	}
+	col, _ := excelize.ColumnNumberToName(msgCol)
+	if err = f.SetColWidth(s.SheetName, col, col, 40); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// mergeFillStyle 在单元格原有样式上叠加填充色，保留字体、边框、数字格式和对齐方式
+// excelize 没有读取样式的接口，直接复制 cellXfs 中的样式记录
+func mergeFillStyle(f *excelize.File, style, fillStyle int) int {
+	if f.Styles == nil || f.Styles.CellXfs == nil || style <= 0 || style >= len(f.Styles.CellXfs.Xf) || fillStyle >= len(f.Styles.CellXfs.Xf) {
+		return fillStyle
+	}
+	applyFill := true
+	xf := f.Styles.CellXfs.Xf[style]
+	xf.FillID = f.Styles.CellXfs.Xf[fillStyle].FillID
+	xf.ApplyFill = &applyFill
+	f.Styles.CellXfs.Xf = append(f.Styles.CellXfs.Xf, xf)
+	f.Styles.CellXfs.Count = len(f.Styles.CellXfs.Xf)
+	return len(f.Styles.CellXfs.Xf) - 1
+}
@@ -0,0 +1,34 @@
+package structexcel
+
+import (
+	"reflect"
+)
+
+// setRowMeta 写入行号字段，实现了 ExcelRowSetter 时传入行号和原始单元格内容
+// row 为excel中的行号，不受空行和表头行影响
+func (s *Sheet) setRowMeta(itemPtr reflect.Value, schema *typeSchema, row int, cells []string) {
+	if schema.rowNum >= 0 {
+		field := itemPtr.Elem().Field(schema.rowNum)
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			field.SetInt(int64(row))
+		default:
+			field.SetUint(uint64(row))
+		}
+	}
+	setter, ok := itemPtr.Interface().(ExcelRowSetter)
+	if !ok {
+		return
+	}
+	raw := make(map[string]string)
+	for _, h := range s.header {
+		if !h.isMatch {
+			continue
+		}
+		raw[h.headerName] = ""
+		if h.Col <= len(cells) {
+			raw[h.headerName] = cells[h.Col-1]
+		}
+	}
+	setter.SetExcelRow(row, raw)
+}
@@ -0,0 +1,92 @@
+package structexcel
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// typeSchema struct类型解析后的表头，按类型缓存，AddData 和 ReadData 共用
+type typeSchema struct {
+	fields excelHeaderSlice // 不包括跳过的字段，使用前需要 clone
+	rowNum int              // excel:"-,rownum" 字段index，没有为-1
+}
+
+type schemaEntry struct {
+	schema *typeSchema
+	err    error
+}
+
+// schemaCache map[reflect.Type]*schemaEntry
+var schemaCache sync.Map
+
+// getSchema 获取struct类型的表头，tag和正则只解析一次
+func getSchema(typee reflect.Type) (*typeSchema, error) {
+	if v, ok := schemaCache.Load(typee); ok {
+		entry := v.(*schemaEntry)
+		return entry.schema, entry.err
+	}
+	schema, err := parseSchema(typee)
+	v, _ := schemaCache.LoadOrStore(typee, &schemaEntry{schema: schema, err: err})
+	entry := v.(*schemaEntry)
+	return entry.schema, entry.err
+}
+
+func parseSchema(typee reflect.Type) (*typeSchema, error) {
+	schema := &typeSchema{fields: make(excelHeaderSlice, 0, typee.NumField()), rowNum: -1}
+	for i := 0; i < typee.NumField(); i++ {
+		field := typee.Field(i)
+		header, err := ParseExcelHeaderTag(field.Tag.Get("excel"), 0)
+		if err != nil {
+			return nil, withTagField(err, field.Name)
+		}
+		if header.rowNum {
+			switch field.Type.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			default:
+				return nil, &TagError{Field: field.Name, Tag: field.Tag.Get("excel"), Option: "rownum", Err: errors.New("rownum字段必须是整数类型")}
+			}
+			schema.rowNum = i
+		}
+		if header.IsSkip() {
+			continue
+		}
+		header.fieldName = field.Name
+		header.index = i
+		// 不支持 *map，nil指针无法遍历扩展列
+		if header.expand && field.Type.Kind() != reflect.Map {
+			return nil, &TagError{Field: field.Name, Tag: field.Tag.Get("excel"), Option: "expand", Err: errors.New("expand字段必须是map类型")}
+		}
+		schema.fields = append(schema.fields, header)
+	}
+	if err := checkHeaders(typee, schema.fields); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// expandChild 扩展列的表头，继承map value的转换选项，导出和导入使用相同的文案
+func (e *excelHeaderField) expandChild(name string, col int) *excelHeaderField {
+	return &excelHeaderField{
+		Col:        col,
+		fieldName:  e.fieldName,
+		headerName: name,
+		level:      2,
+		index:      e.index,
+		dateLayout: e.dateLayout,
+		boolTrue:   e.boolTrue,
+		boolFalse:  e.boolFalse,
+		enum:       e.enum,
+		enumName:   e.enumName,
+		lookup:     e.lookup,
+	}
+}
+
+// clone 复制表头，Col、isMatch 等字段每个sheet不同
+func (e *excelHeaderField) clone(col int) *excelHeaderField {
+	h := *e
+	h.Col = col
+	return &h
+}
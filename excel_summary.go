@@ -0,0 +1,132 @@
+package structexcel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// subtotalFunctions agg tag 对应 SUBTOTAL 的函数编号
+var subtotalFunctions = map[string]int{
+	"avg":   1,
+	"count": 3,
+	"max":   4,
+	"min":   5,
+	"sum":   9,
+}
+
+type summaryOption struct {
+	on    bool
+	label string
+}
+
+// SetSummaryRow 设置 AddData 结束后是否追加汇总行，汇总方式由字段的 agg tag 决定
+// ReadData 会自动跳过末尾的汇总行
+func (s *Sheet) SetSummaryRow(on bool) {
+	s.summary.on = on
+}
+
+// SetSummaryLabel 设置汇总行第一列的文案，默认：合计
+func (s *Sheet) SetSummaryLabel(label string) {
+	s.summary.label = label
+}
+
+func (s *Sheet) summaryLabel() string {
+	if s.summary.label == "" {
+		return "合计"
+	}
+	return s.summary.label
+}
+
+// hasSummary 是否有需要汇总的字段
+func (s *Sheet) hasSummary() bool {
+	for _, v := range s.header {
+		if v.agg != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// addSummaryRow 在数据下方追加汇总行，使用 SUBTOTAL 公式汇总 start 到 end 行
+func (s *Sheet) addSummaryRow(start, end int) error {
+	if end < start || !s.hasSummary() {
+		return nil
+	}
+	s.addRow()
+	fieldMap := s.header.getFieldMap()
+	maxCol := 1
+	labelCol := true
+	for _, v := range s.header {
+		if v.IsSkip() || v.allowEmpty || v.expand {
+			continue
+		}
+		if v.Col > maxCol {
+			maxCol = v.Col
+		}
+		agg := v.agg
+		// 扩展列使用扩展字段的汇总方式
+		if v.level == 2 {
+			if parent, ok := fieldMap[v.fieldName]; ok {
+				agg = parent.agg
+			}
+		}
+		if agg == "" {
+			continue
+		}
+		if v.Col == 1 {
+			labelCol = false
+		}
+		col, err := excelize.ColumnNumberToName(v.Col)
+		if err != nil {
+			return err
+		}
+		axis, _ := s.axis(s.row, v.Col)
+		formula := fmt.Sprintf("SUBTOTAL(%d,%s%d:%s%d)", subtotalFunctions[agg], col, start, col, end)
+		if err = s.Excel.SetCellFormula(s.SheetName, axis, formula); err != nil {
+			return err
+		}
+	}
+	if labelCol {
+		axis, _ := s.axis(s.row, 1)
+		if err := s.Excel.SetCellValue(s.SheetName, axis, s.summaryLabel()); err != nil {
+			return err
+		}
+	}
+	return s.setSummaryStyle(s.row, maxCol)
+}
+
+// setSummaryStyle 汇总行加粗并添加上边框
+func (s *Sheet) setSummaryStyle(row, maxCol int) error {
+	style, err := s.Excel.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Border: []excelize.Border{{Type: "top", Color: "000000", Style: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	hCell, _ := s.axis(row, 1)
+	vCell, err := s.axis(row, maxCol)
+	if err != nil {
+		return err
+	}
+	return s.Excel.SetCellStyle(s.SheetName, hCell, vCell, style)
+}
+
+// trimSummaryRow 导入时去掉末尾的汇总行，只按汇总列的 SUBTOTAL 公式识别，第一列是“合计”的数据行不受影响
+func (s *Sheet) trimSummaryRow(rows [][]string, rowNums []int) ([][]string, []int) {
+	if len(rows) == 0 || !s.hasSummary() {
+		return rows, rowNums
+	}
+	for _, v := range s.header {
+		if v.agg == "" || !v.isMatch {
+			continue
+		}
+		axis, _ := s.axis(rowNums[len(rowNums)-1], v.Col)
+		if formula, _ := s.Excel.GetCellFormula(s.SheetName, axis); strings.HasPrefix(formula, "SUBTOTAL(") {
+			return rows[:len(rows)-1], rowNums[:len(rowNums)-1]
+		}
+	}
+	return rows, rowNums
+}
@@ -0,0 +1,70 @@
+package structexcel
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+type tableOption struct {
+	freeze     bool
+	autoFilter bool
+	name       string
+	style      string
+}
+
+// SetFreezeHeader AddData 后冻结表头（包括备注和汇总表头）
+func (s *Sheet) SetFreezeHeader(on bool) {
+	s.table.freeze = on
+}
+
+// SetAutoFilter AddData 后对表头和数据区域开启筛选
+func (s *Sheet) SetAutoFilter(on bool) {
+	s.table.autoFilter = on
+}
+
+// SetTable AddData 后将表头和数据区域转换为Excel表格，style如：TableStyleMedium2，为空使用默认样式
+// 表格自带筛选，设置后 SetAutoFilter 不再生效
+func (s *Sheet) SetTable(name, style string) {
+	s.table.name = name
+	s.table.style = style
+}
+
+// applyTableOption 表头位置确定后设置冻结、筛选和表格，end 为最后一行数据
+func (s *Sheet) applyTableOption(end int) error {
+	if s.headerRow == 0 || s.headerCols == 0 {
+		return nil
+	}
+	if s.table.freeze {
+		topLeft, _ := s.axis(s.headerRow+1, 1)
+		if err := s.Excel.SetPanes(s.SheetName, &excelize.Panes{
+			Freeze:      true,
+			YSplit:      s.headerRow,
+			TopLeftCell: topLeft,
+			ActivePane:  "bottomLeft",
+		}); err != nil {
+			return errors.Wrap(err, "冻结表头失败")
+		}
+	}
+
+	hCell, _ := s.axis(s.headerRow, 1)
+	vCell, err := s.axis(end, s.headerCols)
+	if err != nil {
+		return err
+	}
+	if s.table.name != "" && end > s.headerRow {
+		if err = s.Excel.AddTable(s.SheetName, &excelize.Table{
+			Range:     hCell + ":" + vCell,
+			Name:      s.table.name,
+			StyleName: s.table.style,
+		}); err != nil {
+			return errors.Wrap(err, "创建表格失败")
+		}
+		return nil
+	}
+	if s.table.autoFilter {
+		if err = s.Excel.AutoFilter(s.SheetName, hCell+":"+vCell, nil); err != nil {
+			return errors.Wrap(err, "设置筛选失败")
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -13,6 +14,7 @@ type excelHeaderField struct {
 	Col int
 
 	fieldName   string
+	index       int // struct字段index
 	headerName  string
 	allowEmpty  bool
 	expand      bool
@@ -23,8 +25,29 @@ type excelHeaderField struct {
 	font        *excelize.Font
 	isMatch     bool
 	link        bool
+	required    bool
+	example     string
+	defaultVal  string // 导入时空单元格或者缺少列的默认值
+	rowNum      bool   // excel:"-,rownum" 导入时写入行号
+	unique      bool
+	keyGroup    string // key:组名，同组字段组合唯一
+	lookup      *lookupRef
+	width       float64
+	formula     string
+	agg         string
+	image       bool
+	imageHeight float64
+	comment     string
+	expandOrder *ExpandOrder
+	dateLayout  string // expand:date|datetime|month 对应的时间格式，用于 time.Time 类型的map key
+	boolTrue    string
+	boolFalse   string
+	enum        []EnumItem
+	enumName    string // 通过 RegisterEnum 注册的枚举
 }
 
+var formulaFieldRegex = regexp.MustCompile(`\{[^{}]+\}`)
+
 type excelHeaderNode struct {
 	Start    int
 	Height   int
@@ -33,17 +56,50 @@ type excelHeaderNode struct {
 	Children []*excelHeaderNode
 }
 
-func ParseExcelHeaderTag(tag string, col int) *excelHeaderField {
+// TagError excel tag 解析错误
+type TagError struct {
+	Field  string // 字段名，ParseExcelHeaderTag 不知道字段名时为空
+	Tag    string // 完整tag
+	Option string // 出错的tag选项
+	Err    error
+}
+
+func (e *TagError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("excel tag(%s)选项(%s)无效: %s", e.Tag, e.Option, e.Err.Error())
+	}
+	return fmt.Sprintf("字段%s excel tag(%s)选项(%s)无效: %s", e.Field, e.Tag, e.Option, e.Err.Error())
+}
+
+func (e *TagError) Cause() error { return e.Err }
+
+func (e *TagError) Unwrap() error { return e.Err }
+
+func ParseExcelHeaderTag(tag string, col int) (*excelHeaderField, error) {
 	h := &excelHeaderField{
 		Col:   col,
 		level: 1,
 	}
 	if tag == "" || tag == "-" {
 		h.skip = true
-		return h
+		return h, nil
+	}
+	tagErr := func(option string, err error) error {
+		return &TagError{Tag: tag, Option: option, Err: err}
+	}
+	// 不导出的字段只支持 rownum
+	if strings.HasPrefix(tag, "-,") {
+		h.skip = true
+		for _, v := range splitTag(tag)[1:] {
+			if v != "rownum" {
+				return nil, tagErr(v, errors.New("跳过的字段只支持 -,rownum"))
+			}
+			h.rowNum = true
+		}
+		return h, nil
 	}
 
-	tagList := strings.Split(tag, ",")
+	tagList := splitTag(tag)
 	for k, v := range tagList {
 		if v == "allowempty" {
 			h.allowEmpty = true
@@ -51,7 +107,27 @@ func ParseExcelHeaderTag(tag string, col int) *excelHeaderField {
 
 		if strings.HasPrefix(v, "expand:") {
 			h.expand = true
-			h.parseExpand(v)
+			if err := h.parseExpand(v); err != nil {
+				return nil, tagErr(v, err)
+			}
+		}
+
+		if strings.HasPrefix(v, "sort:") {
+			mode, ok := expandSortNames[v[5:]]
+			if !ok {
+				return nil, tagErr(v, errors.New("支持 sort:lexical|natural|date|keys"))
+			}
+			if h.expandOrder == nil {
+				h.expandOrder = &ExpandOrder{}
+			}
+			h.expandOrder.Sort = mode
+		}
+
+		if strings.HasPrefix(v, "keys:") {
+			if h.expandOrder == nil {
+				h.expandOrder = &ExpandOrder{Sort: ExpandSortKeys}
+			}
+			h.expandOrder.Keys = strings.Split(v[5:], "|")
 		}
 
 		if strings.HasPrefix(v, "split:") {
@@ -59,18 +135,21 @@ func ParseExcelHeaderTag(tag string, col int) *excelHeaderField {
 		}
 
 		if strings.HasPrefix(v, "font{") {
+			if !strings.HasSuffix(v, "}") {
+				return nil, tagErr(v, errors.New("富文本格式：font{size:14 color:FF0000}"))
+			}
 			h.font = &excelize.Font{}
 			prop := v[5 : len(v)-1]
 			for _, f := range strings.Split(prop, " ") {
 				rich := strings.Split(f, ":")
 				if len(rich) != 2 {
-					panic("无效富文本tag：font{size:14 color:FF0000} 当前：" + prop)
+					return nil, tagErr(v, errors.New("富文本格式：font{size:14 color:FF0000}"))
 				}
 				switch rich[0] {
 				case "size":
 					s, err := strconv.ParseFloat(rich[1], 64)
 					if err != nil {
-						panic(fmt.Sprintf("excel font fontsize parse error: %s", err.Error()))
+						return nil, tagErr(v, errors.Wrap(err, "字体大小"))
 					}
 					h.font.Size = s
 				case "bold":
@@ -81,7 +160,7 @@ func ParseExcelHeaderTag(tag string, col int) *excelHeaderField {
 					if len(rich[1]) == 6 {
 						h.font.Color = rich[1]
 					} else {
-						panic("无效富文本tag color：font{size:14 color:FF0000} 当前：" + rich[1])
+						return nil, tagErr(v, errors.Errorf("颜色必须是6位16进制：%s", rich[1]))
 					}
 				case "italic":
 					if rich[1] == "true" {
@@ -107,25 +186,153 @@ func ParseExcelHeaderTag(tag string, col int) *excelHeaderField {
 			h.link = true
 		}
 
+		if v == "image" || strings.HasPrefix(v, "image:") {
+			h.image = true
+			if len(v) > 6 {
+				height, err := strconv.ParseFloat(v[6:], 64)
+				if err != nil {
+					return nil, tagErr(v, errors.Wrap(err, "图片高度"))
+				}
+				h.imageHeight = height
+			}
+		}
+
+		if strings.HasPrefix(v, "comment:") {
+			h.comment = v[8:]
+		}
+
+		if strings.HasPrefix(v, "bool:") {
+			words := strings.Split(v[5:], "|")
+			if len(words) != 2 || words[0] == "" || words[1] == "" {
+				return nil, tagErr(v, errors.New("格式：bool:是|否"))
+			}
+			h.boolTrue, h.boolFalse = words[0], words[1]
+		}
+
+		if strings.HasPrefix(v, "enum:") {
+			items, name, err := parseEnum(v[5:])
+			if err != nil {
+				return nil, tagErr(v, err)
+			}
+			h.enum, h.enumName = items, name
+		}
+
+		if v == "unique" {
+			h.unique = true
+		}
+
+		if strings.HasPrefix(v, "key:") {
+			if v[4:] == "" {
+				return nil, tagErr(v, errors.New("格式：key:组名"))
+			}
+			h.keyGroup = v[4:]
+		}
+
+		if strings.HasPrefix(v, "lookup:") {
+			ref, err := parseLookup(v[7:])
+			if err != nil {
+				return nil, tagErr(v, err)
+			}
+			h.lookup = ref
+		}
+
+		if v == "required" {
+			h.required = true
+		}
+
+		if strings.HasPrefix(v, "example:") {
+			h.example = v[8:]
+		}
+
+		if strings.HasPrefix(v, "default:") {
+			h.defaultVal = v[8:]
+		}
+
+		if strings.HasPrefix(v, "formula:") {
+			h.formula = strings.TrimPrefix(v[8:], "=")
+		}
+
+		if strings.HasPrefix(v, "agg:") {
+			h.agg = v[4:]
+			if _, ok := subtotalFunctions[h.agg]; !ok {
+				return nil, tagErr(v, errors.New("支持 agg:sum|avg|count|min|max"))
+			}
+		}
+
+		if strings.HasPrefix(v, "width:") {
+			w, err := strconv.ParseFloat(v[6:], 64)
+			if err != nil {
+				return nil, tagErr(v, errors.Wrap(err, "列宽"))
+			}
+			h.width = w
+		}
+
 		if k == 0 {
 			h.headerName = v
 		}
 	}
 
-	return h
+	return h, nil
+}
+
+// splitTag 按英文逗号分隔tag，忽略括号内的逗号，如 formula:{单价}*{数量} regexp(^\d{1,3}$)
+func splitTag(tag string) []string {
+	res := make([]string, 0)
+	depth := 0
+	start := 0
+	for i := 0; i < len(tag); i++ {
+		switch tag[i] {
+		case '\\':
+			i++
+		case '(', '{':
+			depth++
+		case ')', '}':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				res = append(res, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(res, tag[start:])
 }
 
-func (e *excelHeaderField) parseExpand(expand string) {
+var (
+	expandDateRegex     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	expandDatetimeRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`)
+	expandMonthRegex    = regexp.MustCompile(`^\d{4}-\d{2}$`)
+)
+
+// parseExpand 解析 expand:date|datetime|month|regexp(...)，expand: 为空时只按 keys 或 ExpandOrder 匹配表头
+func (e *excelHeaderField) parseExpand(expand string) error {
 	exp := expand[7:]
-	if strings.HasPrefix(exp, "date") {
-		e.expandRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
-	} else if strings.HasPrefix(exp, "datetime") {
-		e.expandRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`)
-	} else if strings.HasPrefix(exp, "month") {
-		e.expandRegex = regexp.MustCompile(`^\d{4}-\d{2}$`)
-	} else if strings.HasPrefix(exp, "regexp") {
-		e.expandRegex = regexp.MustCompile(exp[7 : len(exp)-1])
+	switch {
+	case exp == "":
+	case exp == "datetime":
+		e.expandRegex = expandDatetimeRegex
+		e.dateLayout = "2006-01-02 15:04:05"
+	case exp == "date":
+		e.expandRegex = expandDateRegex
+		e.dateLayout = "2006-01-02"
+	case exp == "month":
+		e.expandRegex = expandMonthRegex
+		e.dateLayout = "2006-01"
+	case strings.HasPrefix(exp, "regexp"):
+		if !strings.HasPrefix(exp, "regexp(") || !strings.HasSuffix(exp, ")") {
+			return errors.New("正则格式：expand:regexp(^\\d+$)")
+		}
+		r, err := regexp.Compile(exp[7 : len(exp)-1])
+		if err != nil {
+			return errors.Wrap(err, "正则")
+		}
+		e.expandRegex = r
+	default:
+		return errors.Errorf("不支持的扩展类型：%s，可选 date、datetime、month、regexp(...)", exp)
 	}
+	return nil
 }
 
 func (e excelHeaderField) IsSkip() bool {
@@ -152,6 +359,22 @@ func (x excelHeaderSlice) getFieldMap() excelHeaderMap {
 	return res
 }
 
+// getFormulaMap 公式可以引用的字段，key为字段名和表头名
+func (x excelHeaderSlice) getFormulaMap() excelHeaderMap {
+	res := make(excelHeaderMap, 0)
+	for _, v := range x {
+		if v.level == 1 {
+			res[v.headerName] = v
+		}
+	}
+	for _, v := range x {
+		if v.level == 1 {
+			res[v.fieldName] = v
+		}
+	}
+	return res
+}
+
 func (x excelHeaderSlice) getHeaderMap() excelHeaderMap {
 	res := make(excelHeaderMap, 0)
 	for _, v := range x {
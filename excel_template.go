@@ -0,0 +1,281 @@
+package structexcel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// TemplateInstructionSheet 模板填写说明sheet名称
+var TemplateInstructionSheet = "填写说明"
+
+// templateRows 模板数据校验覆盖的行数
+const templateRows = 1000
+
+// templateExampleComment 示例行第一个单元格的批注，ReadData 只跳过带有这个批注的示例行
+const templateExampleComment = "示例行，导入时跳过"
+
+// WriteTemplate 根据struct定义生成空白导入模板：备注、汇总表头、字段表头、列样式、数据校验、示例行和填写说明
+// 生成的模板可以直接用 ReadData 读取
+func (s *Sheet) WriteTemplate(prototype interface{}) error {
+	value := getElem(reflect.ValueOf(prototype))
+	if value.Kind() != reflect.Struct {
+		return errors.New("模板原型必须是struct")
+	}
+
+	if !s.hasRemarks {
+		if r, ok := value.Interface().(ExcelRemarks); ok {
+			remarks, height, width := r.Remarks()
+			if err := s.AddRemark(remarks, height, width); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.transferHeaders(value); err != nil {
+		return errors.Wrap(err, "创建表头失败")
+	}
+	// 模板用于导入，allowempty的列也需要展示
+	for _, v := range s.header {
+		v.allowEmpty = false
+	}
+	if err := s.writeHeader(value); err != nil {
+		return errors.Wrap(err, "创建表头失败")
+	}
+
+	if err := s.writeLookupSheets(); err != nil {
+		return err
+	}
+	headerRow := s.row
+	for _, v := range s.header {
+		if v.IsSkip() || v.expand {
+			continue
+		}
+		if err := s.templateColumn(value.FieldByName(v.fieldName).Type(), v, headerRow); err != nil {
+			return err
+		}
+	}
+	if err := s.writeExample(); err != nil {
+		return err
+	}
+	if err := s.applyTableOption(s.row); err != nil {
+		return err
+	}
+	return s.writeInstructions(value)
+}
+
+// writeExample 表头下方写入示例行，并在第一个示例单元格添加批注作为标记
+func (s *Sheet) writeExample() error {
+	if !s.hasExample() {
+		return nil
+	}
+	s.addRow()
+	marked := false
+	for _, v := range s.header {
+		if v.IsSkip() || v.expand || v.example == "" {
+			continue
+		}
+		axis, err := s.axis(s.row, v.Col)
+		if err != nil {
+			return err
+		}
+		if err = s.Excel.SetCellValue(s.SheetName, axis, v.example); err != nil {
+			return err
+		}
+		if marked {
+			continue
+		}
+		marked = true
+		if err = s.Excel.AddComment(s.SheetName, excelize.Comment{
+			Author: s.commentAuthor,
+			Cell:   axis,
+			Runs:   []excelize.RichTextRun{{Text: templateExampleComment}},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sheet) hasExample() bool {
+	for _, v := range s.header {
+		if v.example != "" && v.level == 1 && !v.expand {
+			return true
+		}
+	}
+	return false
+}
+
+// trimExampleRow 导入时去掉 WriteTemplate 生成的示例行：第一行数据带有示例批注，并且有示例的列和示例一致
+// 导出的数据没有示例批注，和示例一致的数据行也不会被跳过
+func (s *Sheet) trimExampleRow(rows [][]string, rowNums []int) ([][]string, []int, error) {
+	if len(rows) == 0 || !s.hasExample() {
+		return rows, rowNums, nil
+	}
+	comments, err := s.readComments()
+	if err != nil {
+		return nil, nil, err
+	}
+	marked := false
+	for axis, text := range comments {
+		if _, row, err := excelize.CellNameToCoordinates(axis); err == nil && row == rowNums[0] && text == templateExampleComment {
+			marked = true
+		}
+	}
+	if !marked {
+		return rows, rowNums, nil
+	}
+	for _, v := range s.header {
+		if !v.isMatch || v.level != 1 || v.expand {
+			continue
+		}
+		cell := ""
+		if v.Col <= len(rows[0]) {
+			cell = strings.TrimSpace(rows[0][v.Col-1])
+		}
+		if cell != v.example {
+			return rows, rowNums, nil
+		}
+	}
+	return rows[1:], rowNums[1:], nil
+}
+
+// templateColumn 设置模板列宽、列样式、必填表头样式和数据校验
+func (s *Sheet) templateColumn(field reflect.Type, header *excelHeaderField, headerRow int) error {
+	col, err := excelize.ColumnNumberToName(header.Col)
+	if err != nil {
+		return errors.Wrap(err, "excelize")
+	}
+	if header.width > 0 {
+		if err = s.Excel.SetColWidth(s.SheetName, col, col, header.width); err != nil {
+			return err
+		}
+	}
+	for field.Kind() == reflect.Ptr {
+		field = field.Elem()
+	}
+	// 文本列设置为文本格式，避免数字被Excel转换
+	if field.Kind() == reflect.String {
+		style, err := s.Excel.NewStyle(&excelize.Style{NumFmt: 49})
+		if err != nil {
+			return err
+		}
+		if err = s.Excel.SetColStyle(s.SheetName, col, style); err != nil {
+			return err
+		}
+	}
+	if header.required {
+		axis, _ := s.axis(headerRow, header.Col)
+		style, err := s.Excel.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Color: "FF0000"}})
+		if err != nil {
+			return err
+		}
+		if err = s.Excel.SetCellStyle(s.SheetName, axis, axis, style); err != nil {
+			return err
+		}
+	}
+
+	dv := templateValidation(field, header)
+	if header.lookup != nil {
+		dv = s.lookupValidation(header)
+	}
+	if dv == nil {
+		return nil
+	}
+	start, _ := s.axis(headerRow+1, header.Col)
+	end, _ := s.axis(headerRow+templateRows, header.Col)
+	dv.SetSqref(start + ":" + end)
+	return s.Excel.AddDataValidation(s.SheetName, dv)
+}
+
+// templateValidation 根据字段类型生成数据校验
+func templateValidation(field reflect.Type, header *excelHeaderField) *excelize.DataValidation {
+	dv := excelize.NewDataValidation(!header.required)
+	var err error
+	if header.hasEnum() {
+		items, err := header.enumItems()
+		if err != nil || dv.SetDropList(enumLabels(items)) != nil {
+			return nil
+		}
+		dv.SetError(excelize.DataValidationErrorStyleStop, header.headerName, "请从下拉列表中选择")
+		return dv
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		err = dv.SetRange(-2147483648, 2147483647, excelize.DataValidationTypeWhole, excelize.DataValidationOperatorBetween)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		err = dv.SetRange(0, 2147483647, excelize.DataValidationTypeWhole, excelize.DataValidationOperatorBetween)
+	case reflect.Float32, reflect.Float64:
+		err = dv.SetRange(-1e15, 1e15, excelize.DataValidationTypeDecimal, excelize.DataValidationOperatorBetween)
+	case reflect.Bool:
+		err = dv.SetDropList([]string{fmt.Sprint(formatBool(true, header)), fmt.Sprint(formatBool(false, header))})
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	dv.SetError(excelize.DataValidationErrorStyleStop, header.headerName, "请填写"+templateTypeName(field))
+	return dv
+}
+
+// templateTypeName 填写说明中展示的类型名称
+func templateTypeName(field reflect.Type) string {
+	if field == timeType {
+		return "日期"
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "整数"
+	case reflect.Float32, reflect.Float64:
+		return "数字"
+	case reflect.Bool:
+		return "true/false"
+	default:
+		return "文本"
+	}
+}
+
+// writeInstructions 生成填写说明sheet，包含每列的类型、是否必填和示例
+func (s *Sheet) writeInstructions(value reflect.Value) error {
+	if _, err := s.Excel.NewSheet(TemplateInstructionSheet); err != nil {
+		return err
+	}
+	if err := s.Excel.SetSheetRow(TemplateInstructionSheet, "A1", &[]interface{}{"列名", "类型", "是否必填", "示例"}); err != nil {
+		return err
+	}
+	row := 1
+	for _, v := range s.header {
+		if v.IsSkip() || v.level != 1 {
+			continue
+		}
+		field := value.FieldByName(v.fieldName).Type()
+		for field.Kind() == reflect.Ptr {
+			field = field.Elem()
+		}
+		typeName := templateTypeName(field)
+		if v.expand && field.Kind() == reflect.Map {
+			typeName = "扩展列：" + templateTypeName(field.Elem())
+		}
+		if items, err := v.enumItems(); err == nil && len(items) > 0 {
+			typeName = "可选值：" + strings.Join(enumLabels(items), "、")
+		}
+		if v.lookup != nil {
+			typeName = "从" + v.lookup.sheet + "中选择"
+		}
+		required := "否"
+		if v.required {
+			required = "是"
+		}
+		row += 1
+		axis, _ := s.axis(row, 1)
+		if err := s.Excel.SetSheetRow(TemplateInstructionSheet, axis, &[]interface{}{v.headerName, typeName, required, v.example}); err != nil {
+			return err
+		}
+	}
+	return s.Excel.SetColWidth(TemplateInstructionSheet, "A", "D", 20)
+}
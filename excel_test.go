@@ -1,11 +1,23 @@
 package structexcel
 
 import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
 )
 
 type foo struct {
@@ -97,13 +109,29 @@ func TestParseExcelHeaderTag(t *testing.T) {
 }
 
 func TestReadData(t *testing.T) {
-	excel, err := OpenExcel("helloword.xlsx")
+	filename := filepath.Join(t.TempDir(), "hello.xlsx")
+	writer := NewExcel(filename)
+	sheet, err := writer.AddSheet("hello")
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
+	}
+	age := 28
+	if err = sheet.AddData([]*foo{{Name: "h", Age: &age, Height: 181, Holiday: map[string]bool{"2022-01-27": true}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.SaveAs(); err != nil {
+		t.Fatal(err)
 	}
-	sheet, err := excel.OpenSheet("hello")
+	writer.Close()
+
+	excel, err := OpenExcel(filename)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
+	}
+	defer excel.Close()
+	sheet, err = excel.OpenSheet("hello")
+	if err != nil {
+		t.Fatal(err)
 	}
 	if data, err := sheet.ReadData(foo{}); err != nil {
 		t.Error(err)
@@ -115,3 +143,1646 @@ func TestReadData(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteTemplate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "template.xlsx")
+	excel := NewExcel(filename)
+	sheet, err := excel.AddSheet("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.WriteTemplate(foo{}); err != nil {
+		t.Fatal(err)
+	}
+	// 备注占6行，汇总表头在第7行，字段表头在第8行，allowempty的列也要生成
+	rows, _ := excel.File.GetRows("hello")
+	if len(rows) != 8 || fmt.Sprint(rows[6]) != "[个人信息   假期信息]" || fmt.Sprint(rows[7]) != "[姓名 年龄 身高 网址]" {
+		t.Errorf("模板表头错误：%q", rows)
+	}
+	dvs, _ := excel.File.GetDataValidations("hello")
+	sqrefs := make([]string, 0)
+	for _, dv := range dvs {
+		sqrefs = append(sqrefs, dv.Type+":"+dv.Sqref)
+	}
+	if fmt.Sprint(sqrefs) != "[whole:B9:B1008 whole:C9:C1008]" {
+		t.Errorf("数据校验错误：%v", sqrefs)
+	}
+	if instructions, _ := excel.File.GetRows(TemplateInstructionSheet); len(instructions) != 6 || fmt.Sprint(instructions[4]) != "[假期 扩展列：true/false 否]" {
+		t.Errorf("填写说明错误：%v", instructions)
+	}
+	if err = excel.SaveAs(); err != nil {
+		t.Fatal(err)
+	}
+	excel.Close()
+
+	excel, err = OpenExcel(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer excel.Close()
+	sheet, err = excel.OpenSheet("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := sheet.ReadData(foo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*foo); len(d) != 0 {
+		t.Errorf("模板不应该有数据，当前：%d", len(d))
+	}
+}
+
+type applicant struct {
+	Name   string `excel:"姓名,required,example:张三,width:20"`
+	Age    int    `excel:"年龄,example:18"`
+	Status string `excel:"状态,enum:1=待审核|2=已通过"`
+}
+
+func TestTemplateExample(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "template.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("apply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.WriteTemplate(applicant{}); err != nil {
+		t.Fatal(err)
+	}
+	f := excel.File
+	rows, _ := f.GetRows("apply")
+	if expect := [][]string{{"姓名", "年龄", "状态"}, {"张三", "18"}}; fmt.Sprint(rows) != fmt.Sprint(expect) {
+		t.Errorf("示例行错误：%v", rows)
+	}
+	if width, _ := f.GetColWidth("apply", "A"); width != 20 {
+		t.Errorf("列宽错误：%v", width)
+	}
+	style, _ := f.GetCellStyle("apply", "A1")
+	if font := f.Styles.Fonts.Font[*f.Styles.CellXfs.Xf[style].FontID]; font.Color == nil || font.Color.RGB != "FFFF0000" {
+		t.Errorf("必填表头应该标红：%+v", font.Color)
+	}
+	if comments, _ := f.GetComments("apply"); len(comments) != 1 || comments[0].Cell != "A2" {
+		t.Errorf("示例行批注错误：%+v", comments)
+	}
+	instructions, _ := f.GetRows(TemplateInstructionSheet)
+	if len(instructions) != 4 || fmt.Sprint(instructions[1]) != "[姓名 文本 是 张三]" || instructions[3][1] != "可选值：待审核、已通过" {
+		t.Errorf("填写说明错误：%v", instructions)
+	}
+
+	// 示例行读取时跳过，修改过的示例行按数据读取
+	sheet, _ = excel.OpenSheet("apply")
+	res, err := sheet.ReadData(applicant{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := res.([]*applicant); len(d) != 0 {
+		t.Errorf("示例行不应该读取：%+v", d)
+	}
+	_ = f.SetSheetRow("apply", "A3", &[]interface{}{"李四", 20, "已通过"})
+	_ = f.SetCellValue("apply", "B2", 19)
+	sheet, _ = excel.OpenSheet("apply")
+	if res, err = sheet.ReadData(applicant{}); err != nil {
+		t.Fatal(err)
+	}
+	if d := res.([]*applicant); len(d) != 2 || d[0].Age != 19 || d[1].Status != "2" {
+		t.Errorf("读取错误：%+v", d)
+	}
+
+	// 导出的数据没有示例批注，和示例一致的行也要读取
+	sheet, _ = excel.AddSheet("export")
+	if err = sheet.AddData([]applicant{{"张三", 18, "1"}, {"李四", 20, "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	sheet, _ = excel.OpenSheet("export")
+	if res, err = sheet.ReadData(applicant{}); err != nil {
+		t.Fatal(err)
+	}
+	if d := res.([]*applicant); len(d) != 2 || d[0].Name != "张三" || d[0].Age != 18 {
+		t.Errorf("和示例一致的数据行不应该跳过：%+v", d)
+	}
+
+	// 必填列为空时报单元格错误，缺少必填列时报错
+	_ = f.SetSheetRow("export", "A4", &[]interface{}{"", 21, "1"})
+	sheet, _ = excel.OpenSheet("export")
+	_, err = sheet.ReadData(applicant{})
+	if errs, ok := err.(ImportErrors); !ok || len(errs) != 1 || errs[0].Row != 4 || errs[0].Header != "姓名" {
+		t.Errorf("必填列为空应该报错：%v", err)
+	}
+	_ = f.SetCellValue("export", "A1", "名字")
+	sheet, _ = excel.OpenSheet("export")
+	if _, err = sheet.ReadData(applicant{}); err == nil || !strings.Contains(err.Error(), "缺少必填列") {
+		t.Errorf("缺少必填列应该报错：%v", err)
+	}
+
+	type badWidth struct {
+		Name string `excel:"姓名,width:wide"`
+	}
+	if _, ok := Validate(badWidth{}).(*TagError); !ok {
+		t.Error("列宽错误应该返回TagError")
+	}
+	sheet, _ = excel.AddSheet("bad")
+	if err = sheet.WriteTemplate(badWidth{}); err == nil {
+		t.Error("WriteTemplate应该返回tag错误")
+	}
+}
+
+func TestAddEmptyData(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "empty.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetEmptyData(EmptyDataHeader)
+	if err = sheet.AddData([]*foo{}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := excel.File.GetRows("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 8 || rows[6][0] != "个人信息" || fmt.Sprint(rows[7]) != "[姓名 身高 网址]" {
+		t.Errorf("空数据应该写入备注和表头，当前：%q", rows)
+	}
+
+	// 默认写入占位文案并合并单元格
+	sheet, _ = excel.AddSheet("placeholder")
+	if err = sheet.AddData([]*foo{}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := excel.File.GetCellValue("placeholder", "A1"); v != "没有数据" {
+		t.Errorf("默认占位文案错误：%s", v)
+	}
+	if merged, _ := excel.File.GetMergeCells("placeholder"); len(merged) != 1 || merged[0].GetStartAxis() != "A1" || merged[0].GetEndAxis() != "C1" {
+		t.Errorf("默认合并区域错误：%v", merged)
+	}
+
+	sheet, _ = excel.AddSheet("custom")
+	sheet.SetEmptyPlaceholder("暂无记录", "B2", "B2", 0)
+	if err = sheet.AddData([]*foo{}); err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ = excel.File.GetRows("custom"); fmt.Sprint(rows) != "[[] [ 暂无记录]]" {
+		t.Errorf("自定义占位文案错误：%q", rows)
+	}
+	if merged, _ := excel.File.GetMergeCells("custom"); len(merged) != 0 {
+		t.Errorf("单个单元格不应该合并：%v", merged)
+	}
+
+	sheet, _ = excel.AddSheet("none")
+	sheet.SetEmptyData(EmptyDataNone)
+	if err = sheet.AddData([]*foo{}); err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ = excel.File.GetRows("none"); len(rows) != 0 {
+		t.Errorf("EmptyDataNone不应该写入内容：%q", rows)
+	}
+}
+
+type order struct {
+	Price float64 `excel:"单价"`
+	Count int     `excel:"数量"`
+	Total string  `excel:"合计,formula:ROUND({Price}*{数量},2)"`
+}
+
+func TestFormula(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "formula.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]order{{Price: 1.5, Count: 2}, {Price: 3, Count: 4}}); err != nil {
+		t.Fatal(err)
+	}
+	if formula, _ := excel.File.GetCellFormula("order", "C3"); formula != "ROUND(A3*B3,2)" {
+		t.Errorf("公式解析错误：%s", formula)
+	}
+
+	sheet, err = excel.OpenSheet("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetFormulaMode(FormulaText)
+	data, err := sheet.ReadData(order{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*order); len(d) != 2 || d[1].Total != "ROUND(A3*B3,2)" {
+		t.Errorf("公式读取错误：%+v", d)
+	}
+	if v, _ := excel.File.CalcCellValue("order", "C2"); v != "3" {
+		t.Errorf("公式计算错误：%s", v)
+	}
+
+	// 默认读取公式的计算结果，先写入值再设置公式模拟Excel保存的缓存值
+	_ = excel.File.SetCellValue("order", "C3", 12)
+	_ = excel.File.SetCellFormula("order", "C3", "ROUND(A3*B3,2)")
+	sheet, _ = excel.OpenSheet("order")
+	if data, err = sheet.ReadData(order{}); err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*order); d[1].Total != "12" || d[1].Count != 4 {
+		t.Errorf("公式计算结果读取错误：%+v", d[1])
+	}
+}
+
+type score struct {
+	Name  string  `excel:"姓名"`
+	Score float64 `excel:"分数,agg:avg"`
+	Count int     `excel:"次数,agg:sum"`
+}
+
+func TestSummaryRow(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "summary.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetSummaryRow(true)
+	if err = sheet.AddData([]score{{"a", 90, 1}, {"b", 80, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if formula, _ := excel.File.GetCellFormula("score", "B4"); formula != "SUBTOTAL(1,B2:B3)" {
+		t.Errorf("汇总公式错误：%s", formula)
+	}
+	if formula, _ := excel.File.GetCellFormula("score", "C4"); formula != "SUBTOTAL(9,C2:C3)" {
+		t.Errorf("汇总公式错误：%s", formula)
+	}
+	if label, _ := excel.File.GetCellValue("score", "A4"); label != "合计" {
+		t.Errorf("汇总文案错误：%s", label)
+	}
+	style, _ := excel.File.GetCellStyle("score", "C4")
+	if font := excel.File.Styles.Fonts.Font[*excel.File.Styles.CellXfs.Xf[style].FontID]; font.B == nil || (font.B.Val != nil && !*font.B.Val) {
+		t.Error("汇总行应该加粗")
+	}
+
+	sheet, err = excel.OpenSheet("score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := sheet.ReadData(score{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*score); len(d) != 2 || d[1].Name != "b" || d[1].Count != 2 {
+		t.Errorf("应该跳过汇总行，当前：%+v", d)
+	}
+
+	// 没有汇总公式时，最后一行即使叫“合计”也是数据
+	sheet, err = excel.AddSheet("total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]score{{"a", 90, 1}, {"合计", 80, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	sheet, err = excel.OpenSheet("total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = sheet.ReadData(score{}); err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*score); len(d) != 2 || d[1].Name != "合计" || d[1].Score != 80 {
+		t.Errorf("不应该跳过数据行，当前：%+v", d)
+	}
+}
+
+func TestTableOption(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "table.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetFreezeHeader(true)
+	sheet.SetTable("score", "TableStyleMedium2")
+	if err = sheet.AddData([]score{{"a", 90, 1}, {"b", 80, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	sheet, err = excel.OpenSheet("score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := sheet.ReadData(score{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*score); len(d) != 2 {
+		t.Errorf("读取数据错误，当前：%d", len(d))
+	}
+	sheets := strings.Join(zipFiles(t, excel.File, "xl/worksheets/sheet"), "")
+	if !strings.Contains(sheets, `ySplit="1"`) || !strings.Contains(sheets, `topLeftCell="A2"`) || !strings.Contains(sheets, `state="frozen"`) {
+		t.Errorf("没有冻结表头：%s", sheets)
+	}
+	tables := zipFiles(t, excel.File, "xl/tables/table")
+	if len(tables) != 1 || !strings.Contains(tables[0], `ref="A1:C3"`) ||
+		!strings.Contains(tables[0], `name="score"`) || !strings.Contains(tables[0], `name="TableStyleMedium2"`) {
+		t.Errorf("表格错误：%v", tables)
+	}
+
+	// 筛选区域从表头行开始，包括备注行的偏移
+	excel = NewExcel(filepath.Join(t.TempDir(), "filter.xlsx"))
+	defer excel.Close()
+	sheet, err = excel.AddSheet("score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetAutoFilter(true)
+	if err = sheet.AddRemark("备注", 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]score{{"a", 90, 1}, {"b", 80, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	sheets = strings.Join(zipFiles(t, excel.File, "xl/worksheets/sheet"), "")
+	if !strings.Contains(sheets, `<autoFilter ref="$A$3:$C$5"`) {
+		t.Errorf("筛选区域错误：%s", sheets)
+	}
+}
+
+type product struct {
+	Name  string `excel:"名称"`
+	Photo []byte `excel:"图片,image:40"`
+}
+
+func TestImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	buf := bytes.NewBuffer(nil)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	excel := NewExcel(filepath.Join(t.TempDir(), "image.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第二行只有图片
+	if err = sheet.AddData([]product{{Name: "a", Photo: buf.Bytes()}, {Photo: buf.Bytes()}}); err != nil {
+		t.Fatal(err)
+	}
+	sheet, err = excel.OpenSheet("product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := sheet.ReadData(product{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := data.([]*product)
+	if len(d) != 2 || !bytes.Equal(d[0].Photo, buf.Bytes()) || d[1].Name != "" || !bytes.Equal(d[1].Photo, buf.Bytes()) {
+		t.Errorf("图片读取错误：%d", len(d))
+	}
+
+	// 保存后重新打开，drawing 只解析一次
+	filename := filepath.Join(t.TempDir(), "image_saved.xlsx")
+	saved := NewExcel(filename)
+	sheet, _ = saved.AddSheet("product")
+	rows := make([]product, 0)
+	for i := 0; i < 500; i++ {
+		rows = append(rows, product{Name: fmt.Sprint(i), Photo: buf.Bytes()})
+	}
+	rows = append(rows, product{Name: "text"}, product{Photo: buf.Bytes()})
+	if err = sheet.AddData(rows); err != nil {
+		t.Fatal(err)
+	}
+	if err = saved.SaveAs(); err != nil {
+		t.Fatal(err)
+	}
+	saved.Close()
+	saved, err = OpenExcel(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	sheet, _ = saved.OpenSheet("product")
+	start := time.Now()
+	if data, err = sheet.ReadData(product{}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("图片读取太慢：%s", elapsed)
+	}
+	d = data.([]*product)
+	if len(d) != 502 || d[499].Name != "499" || !bytes.Equal(d[499].Photo, buf.Bytes()) || d[500].Photo != nil || !bytes.Equal(d[501].Photo, buf.Bytes()) {
+		t.Errorf("保存后图片读取错误：%d", len(d))
+	}
+}
+
+type productURL struct {
+	Name  string `excel:"名称"`
+	Photo string `excel:"图片,image"`
+}
+
+func TestImageLoader(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	buf := bytes.NewBuffer(nil)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "photo.png")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	excel := NewExcel(filepath.Join(t.TempDir(), "image_loader.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 默认不读取本地文件和url
+	if err = sheet.AddData([]productURL{{Name: "a", Photo: path}}); err == nil || !strings.Contains(err.Error(), "SetImageLoader") {
+		t.Fatalf("没有设置ImageLoader应该报错：%v", err)
+	}
+
+	sheet, _ = excel.AddSheet("loader")
+	var loaded []string
+	sheet.SetImageLoader(func(src string) ([]byte, error) {
+		loaded = append(loaded, src)
+		return buf.Bytes(), nil
+	})
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	if err = sheet.AddData([]productURL{{Name: "a", Photo: "oss://photo.png"}, {Name: "b", Photo: dataURI}}); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(loaded) != "[oss://photo.png]" {
+		t.Errorf("ImageLoader调用错误：%v", loaded)
+	}
+	for _, axis := range []string{"B2", "B3"} {
+		if pictures, _ := excel.File.GetPictures("loader", axis); len(pictures) != 1 {
+			t.Errorf("%s图片写入错误", axis)
+		}
+	}
+
+	if _, err = HTTPImageLoader(time.Second)("file://" + path); err == nil {
+		t.Error("HTTPImageLoader只支持http(s)")
+	}
+}
+
+type review struct {
+	Name   string `excel:"姓名"`
+	Score  int    `excel:"分数,comment:Reason"`
+	Reason string `excel:"-"`
+}
+
+func TestComment(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "comment.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]review{{"a", 59, "不及格"}, {"b", 90, ""}}); err != nil {
+		t.Fatal(err)
+	}
+	sheet, err = excel.OpenSheet("review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := sheet.ReadData(review{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*review); len(d) != 2 || d[0].Reason != "不及格" || d[1].Reason != "" {
+		t.Errorf("批注读取错误：%+v", d)
+	}
+	// 只有非空字段写入批注
+	comments, _ := excel.File.GetComments("review")
+	if len(comments) != 1 || comments[0].Cell != "B2" {
+		t.Fatalf("批注写入错误：%+v", comments)
+	}
+	text := ""
+	for _, r := range comments[0].Runs {
+		text += r.Text
+	}
+	if !strings.Contains(text, "不及格") {
+		t.Errorf("批注内容错误：%s", text)
+	}
+}
+
+func TestAddChart(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "chart.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddChart(&SheetChart{Type: excelize.Col, Title: "成绩", Category: "姓名", Values: []string{"Score", "次数"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]score{{"a", 90, 1}, {"b", 80, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	// 再次写入数据不会重复生成图表
+	if err = sheet.AddData([]score{{"c", 70, 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddChart(&SheetChart{Type: excelize.Line, Category: "姓名", Values: []string{"不存在"}}); err == nil {
+		t.Error("不存在的字段应该报错")
+	}
+	charts := zipFiles(t, excel.File, "xl/charts/chart")
+	if len(charts) != 1 {
+		t.Fatalf("图表数量错误：%d", len(charts))
+	}
+	for _, ref := range []string{"score&#39;!$A$2:$A$3", "score&#39;!$B$2:$B$3", "score&#39;!$C$2:$C$3", ">成绩<"} {
+		if !strings.Contains(charts[0], ref) {
+			t.Errorf("图表缺少：%s", ref)
+		}
+	}
+
+	// expand字段展开为每个扩展列一个系列
+	sheet, err = excel.AddSheet("monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddChart(&SheetChart{Type: excelize.Line, Category: "姓名", Values: []string{"Month"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]monthly{{Name: "a", Month: map[string]int{"2月": 1, "10月": 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	charts = zipFiles(t, excel.File, "xl/charts/chart")
+	if len(charts) != 2 {
+		t.Fatalf("图表数量错误：%d", len(charts))
+	}
+	all := strings.Join(charts, "")
+	for _, ref := range []string{"monthly&#39;!$B$1", "monthly&#39;!$C$1", "monthly&#39;!$D$1", "monthly&#39;!$D$2"} {
+		if !strings.Contains(all, ref) {
+			t.Errorf("扩展列图表缺少：%s", ref)
+		}
+	}
+
+	sheet, err = excel.AddSheet("empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddChart(&SheetChart{Type: excelize.Col, Category: "姓名", Values: []string{"Score"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]score{}); err != nil {
+		t.Fatal(err)
+	}
+	if charts = zipFiles(t, excel.File, "xl/charts/chart"); len(charts) != 2 {
+		t.Errorf("没有数据时默认不生成图表：%d", len(charts))
+	}
+
+	// 没有数据时按表头生成空图表
+	sheet, err = excel.AddSheet("emptyHeader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetEmptyData(EmptyDataHeader)
+	sheet.SetChartEmpty(ChartEmptyHeader)
+	if err = sheet.AddChart(&SheetChart{Type: excelize.Col, Category: "姓名", Values: []string{"Score"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]score{}); err != nil {
+		t.Fatal(err)
+	}
+	charts = zipFiles(t, excel.File, "xl/charts/chart")
+	if all = strings.Join(charts, ""); len(charts) != 3 || !strings.Contains(all, "emptyHeader&#39;!$A$2") {
+		t.Errorf("空数据图表错误：%d", len(charts))
+	}
+}
+
+// zipFiles 读取保存后的xlsx中以prefix开头的文件内容
+func zipFiles(t *testing.T, f *excelize.File, prefix string) []string {
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make([]string, 0)
+	for _, file := range r.File {
+		if !strings.HasPrefix(file.Name, prefix) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, string(content))
+	}
+	return res
+}
+
+type attendance struct {
+	Name  string  `excel:"姓名"`
+	Date  string  `excel:"日期"`
+	Hours float64 `excel:"工时"`
+}
+
+func TestAddCrossTab(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "crosstab.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("attendance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []attendance{
+		{"b", "2022-01-28", 8},
+		{"a", "2022-01-27", 4},
+		{"a", "2022-01-27", 4},
+		{"a", "2022-01-28", 6},
+	}
+	if err = sheet.AddCrossTab(data, CrossTab{Row: "姓名", Column: "Date", Value: "Hours", Totals: true}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := excel.File.GetRows("attendance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := [][]string{
+		{"姓名", "2022-01-27", "2022-01-28", "合计"},
+		{"a", "8", "6", ""},
+		{"b", "", "8", ""},
+		{"合计", "", "", ""},
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(expect) {
+		t.Errorf("交叉表错误：%v", rows)
+	}
+	// 合计是公式
+	totals := map[string]string{"D2": "SUM(B2:C2)", "D3": "SUM(B3:C3)", "B4": "SUM(B2:B3)", "C4": "SUM(C2:C3)", "D4": "SUM(D2:D3)"}
+	sums := map[string]string{"D2": "14", "D3": "8", "B4": "8", "C4": "14", "D4": "22"}
+	for axis, formula := range totals {
+		if f, _ := excel.File.GetCellFormula("attendance", axis); f != formula {
+			t.Errorf("%s 合计公式错误：%s", axis, f)
+		}
+		if v, _ := excel.File.CalcCellValue("attendance", axis); v != sums[axis] {
+			t.Errorf("%s 合计错误：%s", axis, v)
+		}
+	}
+}
+
+type weekly struct {
+	Name  *string `excel:"姓名"`
+	Week  int     `excel:"周"`
+	Count int     `excel:"次数"`
+}
+
+func (w weekly) GatherHeaderRows() int { return 1 }
+
+func (w weekly) GatherHeader(sheet *Sheet) error {
+	return sheet.Excel.SetCellValue(sheet.SheetName, "A1", "周报")
+}
+
+func TestCrossTabOrder(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "crosstab.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("weekly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "a"
+	data := []weekly{{&name, 10, 1}, {nil, 2, 3}, {&name, 1, 5}, {&name, 2, 2}}
+	if err = sheet.AddCrossTab(data, CrossTab{Row: "Name", Column: "周", Value: "Count", Agg: "max", Totals: true}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := excel.File.GetRows("weekly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 汇总表头在字段表头之上，数字列按自然序，nil行字段为空
+	expect := [][]string{
+		{"周报"},
+		{"姓名", "1", "2", "10", "合计"},
+		{"", "", "3", "", ""},
+		{"a", "5", "2", "1", ""},
+		{"合计", "", "", "", ""},
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(expect) {
+		t.Errorf("交叉表错误：%q", rows)
+	}
+	if f, _ := excel.File.GetCellFormula("weekly", "E5"); f != "MAX(E3:E4)" {
+		t.Errorf("合计公式错误：%s", f)
+	}
+	if v, _ := excel.File.CalcCellValue("weekly", "C5"); v != "3" {
+		t.Errorf("合计错误：%s", v)
+	}
+}
+
+type monthly struct {
+	Name  string         `excel:"姓名"`
+	Month map[string]int `excel:"月份,expand:regexp(^\\d+月$),sort:natural,keys:1月|2月"`
+	Week  map[string]int `excel:"星期,expand:"`
+}
+
+func TestExpandOrder(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "expand.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetExpandOrder("星期", ExpandOrder{Sort: ExpandSortKeys, Keys: []string{"周一", "周二", "周三"}})
+	data := []monthly{
+		{Name: "a", Month: map[string]int{"10月": 1, "2月": 2}, Week: map[string]int{"周三": 1}},
+		{Name: "b", Month: map[string]int{"11月": 3}},
+	}
+	if err = sheet.AddData(data); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := excel.File.GetRows("monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"姓名", "1月", "2月", "10月", "11月", "周一", "周二", "周三"}
+	if fmt.Sprint(rows[0]) != fmt.Sprint(expect) {
+		t.Errorf("扩展列顺序错误：%v", rows[0])
+	}
+
+	// map遍历顺序是随机的，多次导出表头顺序必须一致
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("monthly%d", i)
+		sheet, _ = excel.AddSheet(name)
+		sheet.SetExpandOrder("星期", ExpandOrder{Sort: ExpandSortKeys, Keys: []string{"周一", "周二", "周三"}})
+		if err = sheet.AddData(data); err != nil {
+			t.Fatal(err)
+		}
+		if rows, _ = excel.File.GetRows(name); fmt.Sprint(rows[0]) != fmt.Sprint(expect) {
+			t.Fatalf("第%d次扩展列顺序错误：%v", i, rows[0])
+		}
+	}
+	if _, err = ParseExcelHeaderTag("月份,expand:date,sort:none", 1); err == nil {
+		t.Error("sort:none 不是确定的顺序，应该报错")
+	}
+	type badSpec struct {
+		Week map[string]int `excel:"星期,expand:weeekday"`
+	}
+	if _, ok := Validate(badSpec{}).(*TagError); !ok {
+		t.Error("未知的expand类型应该返回TagError")
+	}
+}
+
+type typedKey struct {
+	Name    string             `excel:"姓名"`
+	Holiday map[time.Time]bool `excel:"假期,expand:date"`
+	Score   map[int]float64    `excel:"分数,expand:regexp(^\\d+$),sort:natural"`
+}
+
+func TestTypedExpandKey(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "typed.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("typed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 本地时区不是UTC时，UTC的key也能原样读回
+	local := time.Local
+	time.Local = time.FixedZone("CST", 8*3600)
+	defer func() { time.Local = local }()
+	day := time.Date(2022, 1, 27, 0, 0, 0, 0, time.UTC)
+	data := []typedKey{{
+		Name:    "a",
+		Holiday: map[time.Time]bool{day: true},
+		Score:   map[int]float64{2: 1.5, 10: 3},
+	}}
+	if err = sheet.AddData(data); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := excel.File.GetRows("typed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"姓名", "2022-01-27", "2", "10"}; fmt.Sprint(rows[0]) != fmt.Sprint(expect) {
+		t.Errorf("扩展表头错误：%v", rows[0])
+	}
+
+	sheet, err = excel.OpenSheet("typed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := sheet.ReadData(typedKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := res.([]*typedKey)
+	if len(d) != 1 || !d[0].Holiday[day] || d[0].Score[10] != 3 || d[0].Score[2] != 1.5 {
+		t.Errorf("扩展字段读取错误：%+v", d[0])
+	}
+
+	// 导出本地时区的key时，导入设置相同的时区
+	localDay := time.Date(2022, 1, 27, 0, 0, 0, 0, time.Local)
+	sheet, _ = excel.AddSheet("local")
+	if err = sheet.AddData([]typedKey{{Name: "b", Holiday: map[time.Time]bool{localDay: true}}}); err != nil {
+		t.Fatal(err)
+	}
+	sheet, _ = excel.OpenSheet("local")
+	sheet.SetExpandLocation(time.Local)
+	if res, err = sheet.ReadData(typedKey{}); err != nil {
+		t.Fatal(err)
+	}
+	if d = res.([]*typedKey); len(d) != 1 || !d[0].Holiday[localDay] {
+		t.Errorf("本地时区扩展字段读取错误：%+v", d[0])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]*foo{}); err != nil {
+		t.Error(err)
+	}
+	type badFont struct {
+		Name string `excel:"姓名,font{size:big}"`
+	}
+	type badExpand struct {
+		Days []string `excel:"日期,expand:date"`
+	}
+	type badFormula struct {
+		Total int `excel:"合计,formula:{Price}*2"`
+	}
+	type expandFormula struct {
+		Month map[string]int `excel:"月份,expand:month"`
+		Total int            `excel:"合计,formula:{Month}*2"`
+	}
+	type allowEmptyFormula struct {
+		Price *int `excel:"单价,allowempty"`
+		Total int  `excel:"合计,formula:{单价}*2"`
+	}
+	type imageFormula struct {
+		Photo []byte `excel:"照片,image"`
+		Total int    `excel:"合计,formula:{Photo}"`
+	}
+	type badExpandPtr struct {
+		Days *map[string]int `excel:"日期,expand:date"`
+	}
+	for _, v := range []interface{}{badFont{}, badExpand{}, badFormula{}, badExpandPtr{}, expandFormula{}, allowEmptyFormula{}, imageFormula{}} {
+		err := Validate(v)
+		if _, ok := err.(*TagError); !ok {
+			t.Errorf("%T 应该返回TagError，当前：%v", v, err)
+		}
+	}
+
+	excel := NewExcel(filepath.Join(t.TempDir(), "bad.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("bad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]badFont{{Name: "a"}}); err == nil {
+		t.Error("AddData应该返回tag错误")
+	}
+	if err = sheet.AddData([]badExpandPtr{{}}); err == nil {
+		t.Error("*map扩展字段应该返回错误而不是panic")
+	}
+	// nil元素返回错误而不是panic
+	if err = sheet.AddData([]*foo{{Name: "a"}, nil}); err == nil || !strings.Contains(err.Error(), "第2条数据为nil") {
+		t.Errorf("nil元素应该返回错误：%v", err)
+	}
+	if err = sheet.AddHeader([]*foo{nil}); err == nil {
+		t.Error("AddHeader nil元素应该返回错误")
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	first, err := getSchema(reflect.TypeOf(reportRow{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := getSchema(reflect.TypeOf(reportRow{})); second != first {
+		t.Error("同一类型应该复用缓存")
+	}
+
+	// 缓存的表头每个sheet单独复制，列顺序不同的sheet互不影响
+	excel := NewExcel(filepath.Join(t.TempDir(), "schema.xlsx"))
+	defer excel.Close()
+	f := excel.File
+	_, _ = f.NewSheet("a")
+	_, _ = f.NewSheet("b")
+	_ = f.SetSheetRow("a", "A1", &[]interface{}{"编号", "年龄"})
+	_ = f.SetSheetRow("a", "A2", &[]interface{}{1, 18})
+	_ = f.SetSheetRow("b", "A1", &[]interface{}{"年龄", "编号"})
+	_ = f.SetSheetRow("b", "A2", &[]interface{}{20, 2})
+	sheetA, _ := excel.OpenSheet("a")
+	sheetB, _ := excel.OpenSheet("b")
+	resA, err := sheetA.ReadData(reportRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resB, err := sheetB.ReadData(reportRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := resA.([]*reportRow)[0], resB.([]*reportRow)[0]; *a != (reportRow{1, 18}) || *b != (reportRow{2, 20}) {
+		t.Errorf("表头缓存被修改：%+v %+v", *a, *b)
+	}
+	if first.fields[0].Col != 0 || first.fields[0].isMatch {
+		t.Errorf("缓存的表头不应该被修改：%+v", first.fields[0])
+	}
+}
+
+type status int8
+
+func TestCellToValue(t *testing.T) {
+	sheet := &Sheet{}
+	cases := []struct {
+		value  interface{}
+		cell   string
+		expect interface{}
+		err    bool
+	}{
+		{int8(0), "127", int8(127), false},
+		{int8(0), "128", nil, true},
+		{int(0), "1,234", 1234, false},
+		{int(0), "-1,234,567", -1234567, false},
+		{int(0), "1,5", nil, true},
+		{int(0), "1,2,3", nil, true},
+		{int(0), "1234,567", nil, true},
+		{float64(0), "1.5,00", nil, true},
+		{int(0), "1.0", 1, false},
+		{int(0), "1e3", 1000, false},
+		{int(0), "1.5", nil, true},
+		{uint(0), "-1", nil, true},
+		{uint8(0), "1,234", nil, true},
+		{uint16(0), "65535", uint16(65535), false},
+		{uint32(0), "", uint32(0), false},
+		{uint64(0), "", uint64(0), false},
+		{float32(0), "12.5%", float32(0.125), false},
+		{float64(0), "1,234.5", 1234.5, false},
+		{status(0), "2", status(2), false},
+	}
+	for _, c := range cases {
+		v, err := sheet.cellToValue(reflect.TypeOf(c.value), c.cell, "A1", nil, numberLocale{})
+		if c.err {
+			if err == nil {
+				t.Errorf("%T(%s) 应该报错", c.value, c.cell)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%T(%s) 转换失败：%s", c.value, c.cell, err)
+			continue
+		}
+		if v.Interface() != c.expect {
+			t.Errorf("%T(%s) 转换错误：%v", c.value, c.cell, v.Interface())
+		}
+	}
+}
+
+type switchItem struct {
+	Name    string  `excel:"名称"`
+	Enabled bool    `excel:"状态,bool:启用|停用"`
+	Public  bool    `excel:"公开"`
+	Price   float64 `excel:"价格"`
+}
+
+func TestBoolVocabulary(t *testing.T) {
+	SetBoolVocabulary([]string{"是", "Y", "√"}, []string{"否", "N", "×"})
+	defer SetBoolVocabulary(nil, nil)
+
+	excel := NewExcel(filepath.Join(t.TempDir(), "bool.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("switch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]switchItem{{"a", true, false, 1.5}}); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := excel.File.GetRows("switch")
+	if fmt.Sprint(rows[1]) != fmt.Sprint([]string{"a", "启用", "否", "1.5"}) {
+		t.Errorf("bool导出错误：%v", rows[1])
+	}
+	_ = excel.File.SetSheetRow("switch", "A3", &[]interface{}{"b", "停用", "√", "1.234,5"})
+	// Excel原生bool和默认词汇总是可以导入
+	_ = excel.File.SetSheetRow("switch", "A4", &[]interface{}{"c", "停用", true, 2})
+	_ = excel.File.SetSheetRow("switch", "A5", &[]interface{}{"d", "启用", "false", 3})
+
+	sheet, err = excel.OpenSheet("switch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetNumberLocale(",", ".")
+	data, err := sheet.ReadData(switchItem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := data.([]*switchItem)
+	if len(d) != 4 || !d[0].Enabled || d[0].Public || d[1].Enabled || !d[1].Public || !d[2].Public || d[3].Public {
+		t.Errorf("bool导入错误：%+v %+v", d[0], d[1])
+	}
+	// 数字单元格不受 SetNumberLocale 影响，文本单元格按locale解析
+	if d[0].Price != 1.5 || d[1].Price != 1234.5 {
+		t.Errorf("数字导入错误：%v %v", d[0].Price, d[1].Price)
+	}
+
+	sheet, _ = excel.OpenSheet("switch")
+	sheet.SetNumberLocale(",", ".")
+	sheet.SetRawCellValue(true)
+	if data, err = sheet.ReadData(switchItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if d = data.([]*switchItem); d[0].Price != 1.5 || d[1].Price != 1234.5 {
+		t.Errorf("原始值不应该按locale解析：%v %v", d[0].Price, d[1].Price)
+	}
+}
+
+type localeRate struct {
+	Name  string          `excel:"名称"`
+	Price float64         `excel:"价格"`
+	Rate  map[float64]int `excel:"费率,expand:regexp(^[\\d.]+$)"`
+}
+
+func TestNumberLocaleExpandKey(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "locale.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("rate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]localeRate{{"a", 1, map[float64]int{1.5: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	_ = excel.File.SetSheetRow("rate", "A3", &[]interface{}{"b", "1.234,5", 3})
+
+	sheet, err = excel.OpenSheet("rate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetNumberLocale(",", ".")
+	data, err := sheet.ReadData(localeRate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 表头不是数据单元格，扩展key不按locale解析
+	d := data.([]*localeRate)
+	if len(d) != 2 || d[0].Rate[1.5] != 2 || d[1].Rate[1.5] != 3 || d[1].Price != 1234.5 {
+		t.Errorf("locale导入错误：%+v %+v", d[0], d[1])
+	}
+}
+
+type audit struct {
+	Name   string `excel:"名称"`
+	Status int8   `excel:"状态,enum:1=待审核|2=已通过|3=已拒绝"`
+	Level  int    `excel:"等级,enum:level"`
+}
+
+func TestEnum(t *testing.T) {
+	RegisterEnum("level", []EnumItem{{"1", "低"}, {"2", "高"}})
+	if err := Validate(audit{}); err != nil {
+		t.Fatal(err)
+	}
+
+	excel := NewExcel(filepath.Join(t.TempDir(), "enum.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sheet.AddData([]audit{{"a", 2, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := excel.File.GetRows("audit")
+	if fmt.Sprint(rows[1]) != fmt.Sprint([]string{"a", "已通过", "低"}) {
+		t.Errorf("枚举导出错误：%v", rows[1])
+	}
+
+	sheet, err = excel.OpenSheet("audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := sheet.ReadData(audit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := data.([]*audit); len(d) != 1 || d[0].Status != 2 || d[0].Level != 1 {
+		t.Errorf("枚举导入错误：%+v", d[0])
+	}
+
+	_ = excel.File.SetCellValue("audit", "B2", "不存在")
+	sheet, _ = excel.OpenSheet("audit")
+	if _, err = sheet.ReadData(audit{}); err == nil || !strings.Contains(err.Error(), "待审核、已通过、已拒绝") {
+		t.Errorf("无效枚举应该报错并列出可选值：%v", err)
+	}
+}
+
+type nullable struct {
+	Name   string          `excel:"名称"`
+	Age    *int            `excel:"年龄"`
+	Remark sql.NullString  `excel:"备注"`
+	Score  sql.NullFloat64 `excel:"分数"`
+}
+
+func TestNullable(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "null.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetNullPlaceholder("-")
+	age := 18
+	data := []nullable{
+		{Name: "a", Age: &age, Remark: sql.NullString{String: "ok", Valid: true}, Score: sql.NullFloat64{Float64: 1.5, Valid: true}},
+		{Name: "b"},
+	}
+	if err = sheet.AddData(data); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := excel.File.GetRows("null")
+	if fmt.Sprint(rows[2]) != fmt.Sprint([]string{"b", "-", "-", "-"}) {
+		t.Errorf("null导出错误：%v", rows[2])
+	}
+
+	sheet, err = excel.OpenSheet("null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetNullPlaceholder("-")
+	res, err := sheet.ReadData(nullable{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := res.([]*nullable)
+	if len(d) != 2 || d[0].Age == nil || *d[0].Age != 18 || d[0].Remark.String != "ok" || !d[0].Score.Valid {
+		t.Errorf("null导入错误：%+v", d[0])
+	}
+	if d[1].Age != nil || d[1].Remark.Valid || d[1].Score.Valid {
+		t.Errorf("空单元格应该导入为null：%+v", d[1])
+	}
+}
+
+type defaultRow struct {
+	Name    string `excel:"名称"`
+	Count   int    `excel:"数量,default:1"`
+	Status  string `excel:"状态,default:2,enum:1=待审核|2=已通过"`
+	Creator string `excel:"创建人"`
+}
+
+func TestReadDefault(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "default.xlsx"))
+	defer excel.Close()
+	if _, err := excel.File.NewSheet("default"); err != nil {
+		t.Fatal(err)
+	}
+	_ = excel.File.SetSheetRow("default", "A1", &[]interface{}{"名称", "数量"})
+	_ = excel.File.SetSheetRow("default", "A2", &[]interface{}{"a", 3})
+	_ = excel.File.SetSheetRow("default", "A3", &[]interface{}{"b"})
+
+	sheet, err := excel.OpenSheet("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := sheet.ReadData(defaultRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := res.([]*defaultRow); len(d) != 2 || d[1].Count != 1 || d[1].Status != "2" || d[1].Creator != "" {
+		t.Errorf("默认值错误：%+v", d)
+	}
+
+	sheet, _ = excel.OpenSheet("default")
+	sheet.SetDefault("创建人", func() string { return "admin" })
+	res, err = sheet.ReadData(defaultRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := res.([]*defaultRow)
+	want := []defaultRow{{"a", 3, "2", "admin"}, {"b", 1, "2", "admin"}}
+	for i := range want {
+		if *d[i] != want[i] {
+			t.Errorf("默认值错误：%+v", *d[i])
+		}
+	}
+}
+
+type rawRow struct {
+	Name   string    `excel:"名称"`
+	Rate   float64   `excel:"比例"`
+	Amount float64   `excel:"金额"`
+	Day    time.Time `excel:"日期"`
+	Text   string    `excel:"文本"`
+}
+
+func TestReadRawCellValue(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "raw.xlsx"))
+	defer excel.Close()
+	f := excel.File
+	if _, err := f.NewSheet("raw"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetSheetRow("raw", "A1", &[]interface{}{"名称", "比例", "金额", "日期", "文本"})
+	_ = f.SetSheetRow("raw", "A2", &[]interface{}{"a", 0.123456, 1234.5, time.Date(2022, 1, 27, 0, 0, 0, 0, time.UTC), 0.123456})
+	percent, _ := f.NewStyle(&excelize.Style{NumFmt: 10})
+	thousands, _ := f.NewStyle(&excelize.Style{NumFmt: 4})
+	date, _ := f.NewStyle(&excelize.Style{NumFmt: 14})
+	_ = f.SetCellStyle("raw", "B2", "B2", percent)
+	_ = f.SetCellStyle("raw", "C2", "C2", thousands)
+	_ = f.SetCellStyle("raw", "D2", "D2", date)
+	_ = f.SetCellStyle("raw", "E2", "E2", percent)
+
+	sheet, err := excel.OpenSheet("raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetRawCellValue(true)
+	res, err := sheet.ReadData(rawRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := res.([]*rawRow)[0]
+	if d.Rate != 0.123456 || d.Amount != 1234.5 || d.Text != "12.35%" {
+		t.Errorf("原始值读取错误：%+v", d)
+	}
+	if d.Day.Format("2006-01-02") != "2022-01-27" {
+		t.Errorf("日期读取错误：%s", d.Day)
+	}
+}
+
+type rowMeta struct {
+	Row  int    `excel:"-,rownum"`
+	Name string `excel:"名称"`
+	Age  int    `excel:"年龄"`
+	raw  map[string]string
+}
+
+func (r *rowMeta) SetExcelRow(row int, raw map[string]string) {
+	r.raw = raw
+}
+
+func TestReadRowMeta(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "rownum.xlsx"))
+	defer excel.Close()
+	f := excel.File
+	if _, err := f.NewSheet("rownum"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetSheetRow("rownum", "A1", &[]interface{}{"名称", "年龄"})
+	_ = f.SetSheetRow("rownum", "A2", &[]interface{}{"a", 1})
+	_ = f.SetSheetRow("rownum", "A4", &[]interface{}{"b", "02"})
+
+	sheet, err := excel.OpenSheet("rownum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := sheet.ReadData(rowMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := res.([]*rowMeta)
+	if len(d) != 2 || d[0].Row != 2 || d[1].Row != 4 {
+		t.Fatalf("行号错误：%+v", d)
+	}
+	if d[1].Age != 2 || d[1].raw["年龄"] != "02" || d[1].raw["名称"] != "b" {
+		t.Errorf("原始内容错误：%+v", d[1])
+	}
+
+	if err = Validate(struct {
+		Row string `excel:"-,rownum"`
+	}{}); err == nil {
+		t.Error("rownum字段不是整数应该报错")
+	}
+}
+
+type period struct {
+	Name  string    `excel:"名称"`
+	Days  int       `excel:"天数"`
+	Start time.Time `excel:"开始日期"`
+	End   time.Time `excel:"结束日期"`
+}
+
+func (p *period) BeforeRow(raw []string) error {
+	if raw[0] == "skip" {
+		return ErrSkipRow
+	}
+	raw[0] = strings.ToUpper(strings.TrimSpace(raw[0]))
+	return nil
+}
+
+func (p *period) Validate() error {
+	if !p.End.After(p.Start) {
+		return fmt.Errorf("结束日期必须晚于开始日期")
+	}
+	return nil
+}
+
+func TestReadHooks(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "hook.xlsx"))
+	defer excel.Close()
+	f := excel.File
+	if _, err := f.NewSheet("hook"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetSheetRow("hook", "A1", &[]interface{}{"名称", "天数", "开始日期", "结束日期"})
+	_ = f.SetSheetRow("hook", "A2", &[]interface{}{" a ", 1, "2022-01-01", "2022-01-02"})
+	_ = f.SetSheetRow("hook", "A3", &[]interface{}{"skip", "x", "2022-01-01", "2022-01-02"})
+	_ = f.SetSheetRow("hook", "A4", &[]interface{}{"b", 1, "2022-01-02", "2022-01-01"})
+	_ = f.SetSheetRow("hook", "A5", &[]interface{}{"c", "x", "2022-01-01", "2022-01-02"})
+
+	sheet, err := excel.OpenSheet("hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sheet.ReadData(period{})
+	errs, ok := err.(ImportErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("错误汇总错误：%v", err)
+	}
+	if errs[0].Row != 4 || errs[0].Col != 0 || errs[1].Row != 5 || errs[1].Col != 2 || errs[1].Header != "天数" {
+		t.Errorf("错误行列错误：%+v %+v", errs[0], errs[1])
+	}
+
+	_ = f.RemoveRow("hook", 5)
+	_ = f.RemoveRow("hook", 4)
+	sheet, _ = excel.OpenSheet("hook")
+	decoded := 0
+	sheet.SetAfterDecode(func(item interface{}) error {
+		decoded++
+		return nil
+	})
+	res, err := sheet.ReadData(period{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := res.([]*period)
+	if len(d) != 1 || d[0].Name != "A" || decoded != 1 {
+		t.Errorf("钩子处理错误：%+v", d)
+	}
+}
+
+type reportRow struct {
+	Code int `excel:"编号"`
+	Age  int `excel:"年龄"`
+}
+
+func TestErrorReport(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "report.xlsx"))
+	defer excel.Close()
+	f := excel.File
+	if _, err := f.NewSheet("report"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetSheetRow("report", "A1", &[]interface{}{"编号", "年龄"})
+	_ = f.SetSheetRow("report", "A2", &[]interface{}{1, 1})
+	_ = f.SetSheetRow("report", "A3", &[]interface{}{"n", "x"})
+	textStyle, _ := f.NewStyle(&excelize.Style{NumFmt: 49, Font: &excelize.Font{Bold: true}})
+	_ = f.SetCellStyle("report", "B3", "B3", textStyle)
+	_ = f.AddComment("report", excelize.Comment{Author: "user", Cell: "B3", Runs: []excelize.RichTextRun{{Text: "用户备注"}}})
+
+	sheet, err := excel.OpenSheet("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sheet.ReadData(reportRow{})
+	errs, ok := err.(ImportErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("错误汇总错误：%v", err)
+	}
+	report, err := sheet.ErrorReport(errs, "错误报告.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer report.Close()
+	if report.Filename != "错误报告.xlsx" {
+		t.Errorf("文件名错误：%s", report.Filename)
+	}
+	rows, _ := report.File.GetRows("report")
+	if len(rows[0]) != 3 || rows[0][2] != ErrorReportHeader || len(rows[1]) > 2 || !strings.Contains(rows[2][2], "A3") || !strings.Contains(rows[2][2], "B3") {
+		t.Errorf("错误信息列错误：%v", rows)
+	}
+	// B3已有批注，追加错误信息而不是新增批注
+	comments, _ := report.File.GetComments("report")
+	byCell := make(map[string]string)
+	for _, c := range comments {
+		for _, r := range c.Runs {
+			byCell[c.Cell] += r.Text
+		}
+	}
+	if len(comments) != 2 || !strings.Contains(byCell["A3"], "转int失败") ||
+		!strings.Contains(byCell["B3"], "用户备注") || !strings.Contains(byCell["B3"], "转int失败") {
+		t.Errorf("批注错误：%v", byCell)
+	}
+	if comments, _ = f.GetComments("report"); len(comments) != 1 {
+		t.Error("原excel不应该修改")
+	}
+	saved, err := report.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopen, err := OpenReader(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopen.Close()
+	if comments, _ = reopen.File.GetComments("report"); len(comments) != 2 {
+		t.Errorf("保存后批注错误：%+v", comments)
+	}
+
+	// 标红保留原来的数字格式和字体
+	style, _ := report.File.GetCellStyle("report", "B3")
+	xf, origin := report.File.Styles.CellXfs.Xf[style], f.Styles.CellXfs.Xf[textStyle]
+	if *xf.NumFmtID != 49 || *xf.FontID != *origin.FontID || *xf.FillID == *origin.FillID {
+		t.Errorf("标红样式错误：numFmt=%d font=%d fill=%d", *xf.NumFmtID, *xf.FontID, *xf.FillID)
+	}
+}
+
+type uniqueRow struct {
+	Code  string `excel:"编码,unique"`
+	Shop  string `excel:"门店,key:shop"`
+	Month int    `excel:"月份,key:shop"`
+	Value int    `excel:"数值"`
+}
+
+func TestReadUnique(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "unique.xlsx"))
+	defer excel.Close()
+	f := excel.File
+	if _, err := f.NewSheet("unique"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetSheetRow("unique", "A1", &[]interface{}{"编码", "门店", "月份", "数值"})
+	_ = f.SetSheetRow("unique", "A2", &[]interface{}{"a", "s1", 1, 1})
+	_ = f.SetSheetRow("unique", "A3", &[]interface{}{"b", "s1", 2, 2})
+	_ = f.SetSheetRow("unique", "A4", &[]interface{}{"a", "s2", 1, 3})
+	_ = f.SetSheetRow("unique", "A5", &[]interface{}{"", "s1", "02", 4})
+
+	sheet, err := excel.OpenSheet("unique")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sheet.ReadData(uniqueRow{})
+	errs, ok := err.(ImportErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("重复校验错误：%v", err)
+	}
+	if errs[0].Row != 4 || errs[0].Header != "编码" || !strings.Contains(errs[0].Error(), "第2行") {
+		t.Errorf("unique错误：%v", errs[0])
+	}
+	if errs[1].Row != 5 || errs[1].Header != "门店" || !strings.Contains(errs[1].Error(), "第3行") {
+		t.Errorf("key错误：%v", errs[1])
+	}
+
+	for mode, want := range map[DuplicateMode][]int{DuplicateKeepFirst: {1, 2}, DuplicateKeepLast: {3, 4}} {
+		sheet, _ = excel.OpenSheet("unique")
+		sheet.SetDuplicateMode(mode)
+		res, err := sheet.ReadData(uniqueRow{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := make([]int, 0)
+		for _, v := range res.([]*uniqueRow) {
+			values = append(values, v.Value)
+		}
+		if fmt.Sprint(values) != fmt.Sprint(want) {
+			t.Errorf("去重模式%d错误：%v", mode, values)
+		}
+	}
+}
+
+type employee struct {
+	Name string `excel:"姓名"`
+	Dept string `excel:"部门,lookup:部门表!A:B"`
+}
+
+func TestLookup(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "lookup.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("员工")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.SetLookup("部门表", []EnumItem{{"D01", "研发部"}, {"D02", "市场部"}})
+	if err = sheet.AddData([]employee{{"a", "D01"}, {"b", "D02"}}); err != nil {
+		t.Fatal(err)
+	}
+	if name := excel.File.GetSheetName(excel.File.GetActiveSheetIndex()); name != "员工" {
+		t.Errorf("当前sheet错误：%s", name)
+	}
+	rows, _ := excel.File.GetRows("员工")
+	if rows[1][1] != "研发部" || rows[2][1] != "市场部" {
+		t.Errorf("lookup导出错误：%v", rows)
+	}
+	lookupRows, _ := excel.File.GetRows("部门表")
+	if len(lookupRows) != 2 || lookupRows[0][0] != "D01" || lookupRows[0][1] != "研发部" {
+		t.Errorf("查找表错误：%v", lookupRows)
+	}
+	dvs, _ := excel.File.GetDataValidations("员工")
+	if len(dvs) != 1 || dvs[0].Sqref != "B2:B3" {
+		t.Errorf("下拉框错误：%+v", dvs)
+	}
+
+	_ = excel.File.SetCellValue("员工", "B3", "D02")
+	_ = excel.File.SetSheetRow("员工", "A4", &[]interface{}{"c", "财务部"})
+	sheet, err = excel.OpenSheet("员工")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sheet.ReadData(employee{})
+	if errs, ok := err.(ImportErrors); !ok || len(errs) != 1 || errs[0].Row != 4 {
+		t.Fatalf("查找表不存在的值应该报错：%v", err)
+	}
+	_ = excel.File.RemoveRow("员工", 4)
+	sheet, _ = excel.OpenSheet("员工")
+	res, err := sheet.ReadData(employee{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := res.([]*employee)
+	if d[0].Dept != "D01" || d[1].Dept != "D02" {
+		t.Errorf("lookup导入错误：%+v %+v", d[0], d[1])
+	}
+}
+
+type bookOrder struct {
+	No    string           `excel:"订单号"`
+	Buyer string           `excel:"客户"`
+	Items []*bookOrderItem `excel:"-"`
+}
+
+type bookOrderItem struct {
+	No    string `excel:"订单号"`
+	Goods string `excel:"商品"`
+	Count int    `excel:"数量"`
+}
+
+type orderBook struct {
+	Orders []*bookOrder     `excel:"sheet:订单"`
+	Items  []*bookOrderItem `excel:"sheet:明细,parent:Orders,join:订单号"`
+}
+
+func TestReadInto(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "book.xlsx"))
+	defer excel.Close()
+	orders, _ := excel.AddSheet("订单")
+	if err := orders.AddData([]bookOrder{{No: "A1", Buyer: "张三"}, {No: "A2", Buyer: "李四"}}); err != nil {
+		t.Fatal(err)
+	}
+	items, _ := excel.AddSheet("明细")
+	if err := items.AddData([]bookOrderItem{{"A1", "苹果", 1}, {"A1", "香蕉", 2}, {"A2", "橙子", 3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	book := &orderBook{}
+	if err := excel.ReadInto(book); err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Orders) != 2 || len(book.Items) != 3 {
+		t.Fatalf("读取错误：%+v", book)
+	}
+	if len(book.Orders[0].Items) != 2 || book.Orders[0].Items[1].Goods != "香蕉" || len(book.Orders[1].Items) != 1 {
+		t.Errorf("父子关联错误：%+v %+v", book.Orders[0], book.Orders[1])
+	}
+
+	_ = excel.File.SetSheetRow("明细", "A5", &[]interface{}{"A3", "西瓜", "x"})
+	_ = excel.File.SetSheetRow("订单", "A4", &[]interface{}{"A4", "王五"})
+	_ = excel.File.SetSheetRow("明细", "A6", &[]interface{}{"A5", "葡萄", 1})
+	err := excel.ReadInto(&orderBook{})
+	errs, ok := err.(ImportErrors)
+	if !ok || len(errs) != 1 || errs[0].Sheet != "明细" || errs[0].Row != 5 {
+		t.Fatalf("错误汇总错误：%v", err)
+	}
+
+	_ = excel.File.SetCellValue("明细", "C5", 1)
+	err = excel.ReadInto(&orderBook{})
+	if errs, ok = err.(ImportErrors); !ok || len(errs) != 2 || errs[0].Row != 5 || errs[1].Row != 6 || !strings.Contains(errs[1].Error(), "明细：A6") {
+		t.Fatalf("父表不存在应该报错：%v", err)
+	}
+}
+
+type expandLabel struct {
+	Name    string          `excel:"姓名"`
+	Checkin map[string]bool `excel:"expand:date,bool:是|否"`
+	Review  map[string]int  `excel:"expand:regexp(^审核),enum:1=待审核|2=已通过"`
+}
+
+func TestExpandLabelRoundTrip(t *testing.T) {
+	excel := NewExcel(filepath.Join(t.TempDir(), "expand_label.xlsx"))
+	defer excel.Close()
+	sheet, err := excel.AddSheet("label")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []expandLabel{{
+		Name:    "a",
+		Checkin: map[string]bool{"2022-01-27": true, "2022-01-28": false},
+		Review:  map[string]int{"审核1": 2},
+	}}
+	if err = sheet.AddData(data); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := excel.File.GetRows("label")
+	if fmt.Sprint(rows[1]) != fmt.Sprint([]string{"a", "是", "否", "已通过"}) {
+		t.Fatalf("扩展列导出错误：%v", rows)
+	}
+
+	sheet, _ = excel.OpenSheet("label")
+	res, err := sheet.ReadData(expandLabel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := res.([]*expandLabel)[0]; !reflect.DeepEqual(*d, data[0]) {
+		t.Errorf("扩展列导入错误：%+v", *d)
+	}
+}
@@ -0,0 +1,128 @@
+package structexcel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DuplicateMode 导入时 unique、key 字段重复的处理方式
+type DuplicateMode int
+
+const (
+	DuplicateError     DuplicateMode = iota // 重复时报错，默认
+	DuplicateKeepFirst                      // 保留第一次出现的行
+	DuplicateKeepLast                       // 保留最后一次出现的行
+)
+
+// SetDuplicateMode 设置导入时重复数据的处理方式
+func (s *Sheet) SetDuplicateMode(mode DuplicateMode) {
+	s.duplicateMode = mode
+}
+
+// uniqueGroups unique字段各自一组，key:组名 相同的字段为一组
+func (s *Sheet) uniqueGroups() []excelHeaderSlice {
+	groups := make([]excelHeaderSlice, 0)
+	keyGroups := make(map[string]int)
+	for _, h := range s.header {
+		if h.level != 1 || h.expand {
+			continue
+		}
+		if h.unique {
+			groups = append(groups, excelHeaderSlice{h})
+		}
+		if h.keyGroup == "" {
+			continue
+		}
+		if i, ok := keyGroups[h.keyGroup]; ok {
+			groups[i] = append(groups[i], h)
+		} else {
+			keyGroups[h.keyGroup] = len(groups)
+			groups = append(groups, excelHeaderSlice{h})
+		}
+	}
+	return groups
+}
+
+// uniqueKey 字段组合的值，全部为空时返回false，空值不参与唯一校验
+func uniqueKey(item reflect.Value, group excelHeaderSlice) (string, string, bool) {
+	parts := make([]string, 0, len(group))
+	display := make([]string, 0, len(group))
+	empty := true
+	for _, h := range group {
+		v := item.Field(h.index)
+		part := ""
+		if !isNull(v) {
+			part = fmt.Sprint(cellValue(v, h))
+		}
+		if part != "" {
+			empty = false
+		}
+		parts = append(parts, part)
+		display = append(display, h.headerName+"="+part)
+	}
+	if len(group) == 1 {
+		return parts[0], parts[0], !empty
+	}
+	return strings.Join(parts, "\x00"), strings.Join(display, ","), !empty
+}
+
+// checkUnique 检查 unique、key 字段在所有行中是否重复，rowNums 为每个item在excel中的行号
+// 按 DuplicateMode 返回重复错误或者去重后的数据和行号
+func (s *Sheet) checkUnique(items reflect.Value, rowNums []int) (reflect.Value, []int, ImportErrors) {
+	groups := s.uniqueGroups()
+	if len(groups) == 0 {
+		return items, rowNums, nil
+	}
+	var errs ImportErrors
+	drop := make(map[int]bool)
+	for _, group := range groups {
+		seen := make(map[string]int)
+		for i := 0; i < items.Len(); i++ {
+			if drop[i] {
+				continue
+			}
+			key, display, ok := uniqueKey(items.Index(i).Elem(), group)
+			if !ok {
+				continue
+			}
+			j, dup := seen[key]
+			if !dup {
+				seen[key] = i
+				continue
+			}
+			switch s.duplicateMode {
+			case DuplicateKeepFirst:
+				drop[i] = true
+			case DuplicateKeepLast:
+				drop[j] = true
+				seen[key] = i
+			default:
+				h := group[0]
+				e := &CellError{Row: rowNums[i], Header: h.headerName}
+				if h.isMatch {
+					e.Col = h.Col
+					axis, _ := s.axis(rowNums[i], h.Col)
+					e.Err = errors.Errorf("%s表格(%s)与第%d行重复", axis, display, rowNums[j])
+				} else {
+					e.Err = errors.Errorf("%s与第%d行重复", display, rowNums[j])
+				}
+				errs = append(errs, e)
+			}
+		}
+	}
+	if len(drop) == 0 {
+		return items, rowNums, errs
+	}
+	res := reflect.MakeSlice(items.Type(), 0, items.Len()-len(drop))
+	resRows := make([]int, 0, items.Len()-len(drop))
+	for i := 0; i < items.Len(); i++ {
+		if !drop[i] {
+			res = reflect.Append(res, items.Index(i))
+			resRows = append(resRows, rowNums[i])
+		}
+	}
+	return res, resRows, errs
+}
@@ -0,0 +1,91 @@
+package structexcel
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Validate 检查struct的excel tag是否有效，prototype 可以是 struct、struct指针或slice，建议在启动时调用
+func Validate(prototype interface{}) error {
+	typee := reflect.TypeOf(prototype)
+	if typee == nil {
+		return errors.New("prototype不能为nil")
+	}
+	for typee.Kind() == reflect.Ptr || typee.Kind() == reflect.Slice {
+		typee = typee.Elem()
+	}
+	if typee.Kind() != reflect.Struct {
+		return errors.New("prototype必须是struct")
+	}
+	schema, err := getSchema(typee)
+	if err != nil {
+		return err
+	}
+	// 命名枚举可能在类型解析之后注册，不缓存检查结果
+	for _, h := range schema.fields {
+		if _, err = h.enumItems(); err != nil {
+			field, _ := typee.FieldByName(h.fieldName)
+			return &TagError{Field: h.fieldName, Tag: field.Tag.Get("excel"), Option: "enum:" + h.enumName, Err: err}
+		}
+	}
+	return nil
+}
+
+// validateType 解析struct所有字段的tag并检查
+func validateType(typee reflect.Type) error {
+	_, err := getSchema(typee)
+	return err
+}
+
+// checkHeaders 检查tag选项之间的引用关系和字段类型
+func checkHeaders(typee reflect.Type, headers excelHeaderSlice) error {
+	fieldMap := make(excelHeaderMap)
+	for _, h := range headers {
+		if h.level == 1 {
+			fieldMap[h.fieldName] = h
+			fieldMap[h.headerName] = h
+		}
+	}
+	for _, h := range headers {
+		if h.level != 1 {
+			continue
+		}
+		field, _ := typee.FieldByName(h.fieldName)
+		tag := field.Tag.Get("excel")
+		if h.comment != "" {
+			target, ok := typee.FieldByName(h.comment)
+			if !ok || derefType(target.Type).Kind() != reflect.String {
+				return &TagError{Field: h.fieldName, Tag: tag, Option: "comment:" + h.comment, Err: errors.New("批注字段不存在或不是string类型")}
+			}
+		}
+		if h.image {
+			t := derefType(field.Type)
+			if t.Kind() != reflect.String && !(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+				return &TagError{Field: h.fieldName, Tag: tag, Option: "image", Err: errors.New("image字段必须是[]byte或string")}
+			}
+		}
+		if h.formula != "" {
+			for _, placeholder := range formulaFieldRegex.FindAllString(h.formula, -1) {
+				name := placeholder[1 : len(placeholder)-1]
+				target, ok := fieldMap[name]
+				if !ok {
+					return &TagError{Field: h.fieldName, Tag: tag, Option: "formula:" + h.formula, Err: errors.Errorf("公式引用的字段不存在：%s", name)}
+				}
+				// expand字段有多列，allowempty字段可能不导出，image字段没有值，都不能被公式引用
+				if target.expand || target.allowEmpty || target.image {
+					return &TagError{Field: h.fieldName, Tag: tag, Option: "formula:" + h.formula, Err: errors.Errorf("公式不能引用expand、allowempty或image字段：%s", name)}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// withTagField 给tag错误补充字段名
+func withTagField(err error, field string) error {
+	if tagErr, ok := err.(*TagError); ok {
+		tagErr.Field = field
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+package structexcel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// workbookField ReadInto 目标struct中对应一个sheet的字段，tag：excel:"sheet:明细,parent:Orders,join:订单号"
+type workbookField struct {
+	index  int
+	name   string
+	sheet  string
+	parent string // 父表字段名
+	join   string // 父子表关联的表头名
+}
+
+func parseWorkbookFields(typee reflect.Type) ([]*workbookField, error) {
+	fields := make([]*workbookField, 0)
+	for i := 0; i < typee.NumField(); i++ {
+		field := typee.Field(i)
+		tag := field.Tag.Get("excel")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		w := &workbookField{index: i, name: field.Name}
+		for _, v := range splitTag(tag) {
+			switch {
+			case strings.HasPrefix(v, "sheet:"):
+				w.sheet = v[6:]
+			case strings.HasPrefix(v, "parent:"):
+				w.parent = v[7:]
+			case strings.HasPrefix(v, "join:"):
+				w.join = v[5:]
+			default:
+				return nil, &TagError{Field: field.Name, Tag: tag, Option: v, Err: errors.New("支持 sheet:名称,parent:字段名,join:表头名")}
+			}
+		}
+		if w.sheet == "" {
+			return nil, &TagError{Field: field.Name, Tag: tag, Option: tag, Err: errors.New("缺少 sheet:名称")}
+		}
+		if (w.parent == "") != (w.join == "") {
+			return nil, &TagError{Field: field.Name, Tag: tag, Option: tag, Err: errors.New("parent 和 join 需要同时设置")}
+		}
+		t := field.Type
+		if t.Kind() != reflect.Slice || derefType(t.Elem()).Kind() != reflect.Struct {
+			return nil, &TagError{Field: field.Name, Tag: tag, Option: "sheet:" + w.sheet, Err: errors.New("sheet字段必须是struct slice")}
+		}
+		fields = append(fields, w)
+	}
+	return fields, nil
+}
+
+// ReadInto 按字段tag读取多个sheet，data 为struct指针，slice字段通过 excel:"sheet:订单" 对应sheet
+// 子表字段设置 parent:父表字段名,join:关联表头名 后，每条数据追加到父表数据中类型相同的slice字段
+// 所有sheet的单元格和行错误汇总为 ImportErrors 返回，CellError.Sheet 为sheet名称
+//
+//	type Workbook struct {
+//		Orders []*Order `excel:"sheet:订单"`
+//		Items  []*Item  `excel:"sheet:明细,parent:Orders,join:订单号"`
+//	}
+func (e *Excel) ReadInto(data interface{}) error {
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("data必须是struct指针")
+	}
+	value = value.Elem()
+	fields, err := parseWorkbookFields(value.Type())
+	if err != nil {
+		return err
+	}
+
+	var errs ImportErrors
+	sheets := make(map[string]*Sheet)
+	failed := make(map[string]bool)
+	for _, w := range fields {
+		sheet, err := e.OpenSheet(w.sheet)
+		if err != nil {
+			return err
+		}
+		field := value.Field(w.index)
+		res, err := sheet.ReadData(reflect.New(derefType(field.Type().Elem())).Interface())
+		if err != nil {
+			sheetErrs, ok := err.(ImportErrors)
+			if !ok {
+				return errors.Wrap(err, w.sheet)
+			}
+			for _, ce := range sheetErrs {
+				ce.Sheet = w.sheet
+			}
+			errs = append(errs, sheetErrs...)
+			failed[w.name] = true
+			continue
+		}
+		items := reflect.ValueOf(res)
+		if field.Type().Elem().Kind() == reflect.Ptr {
+			field.Set(items)
+		} else {
+			slice := reflect.MakeSlice(field.Type(), 0, items.Len())
+			for i := 0; i < items.Len(); i++ {
+				slice = reflect.Append(slice, items.Index(i).Elem())
+			}
+			field.Set(slice)
+		}
+		sheets[w.name] = sheet
+	}
+
+	for _, w := range fields {
+		if w.parent == "" || failed[w.name] || failed[w.parent] {
+			continue
+		}
+		linkErrs, err := linkChildren(value, fields, w, sheets)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, linkErrs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// linkChildren 子表数据按join列追加到父表数据的slice字段，找不到父表数据时报错
+func linkChildren(value reflect.Value, fields []*workbookField, child *workbookField, sheets map[string]*Sheet) (ImportErrors, error) {
+	var parent *workbookField
+	for _, w := range fields {
+		if w.name == child.parent {
+			parent = w
+		}
+	}
+	if parent == nil {
+		return nil, errors.Errorf("%s的父表字段%s不存在", child.name, child.parent)
+	}
+	parentSheet, childSheet := sheets[parent.name], sheets[child.name]
+	parentJoin, ok := parentSheet.header.getHeaderMap()[child.join]
+	if !ok {
+		return nil, errors.Errorf("%s没有关联列%s", parent.sheet, child.join)
+	}
+	childJoin, ok := childSheet.header.getHeaderMap()[child.join]
+	if !ok {
+		return nil, errors.Errorf("%s没有关联列%s", child.sheet, child.join)
+	}
+
+	children := value.Field(child.index)
+	childrenType := children.Type()
+	parents := value.Field(parent.index)
+	// 父表struct中类型和子表字段相同的slice字段
+	target := -1
+	parentType := derefType(parents.Type().Elem())
+	for i := 0; i < parentType.NumField(); i++ {
+		if parentType.Field(i).Type == childrenType {
+			target = i
+			break
+		}
+	}
+	if target == -1 {
+		return nil, errors.Errorf("%s缺少%s类型的字段", parentType.Name(), childrenType)
+	}
+
+	parentMap := make(map[string]reflect.Value)
+	for i := 0; i < parents.Len(); i++ {
+		item := getElem(parents.Index(i))
+		key := fmt.Sprint(cellValue(item.Field(parentJoin.index), parentJoin))
+		if _, ok := parentMap[key]; !ok {
+			parentMap[key] = item
+		}
+	}
+
+	var errs ImportErrors
+	for i := 0; i < children.Len(); i++ {
+		key := fmt.Sprint(cellValue(getElem(children.Index(i)).Field(childJoin.index), childJoin))
+		item, ok := parentMap[key]
+		if !ok {
+			row := 0
+			if i < len(childSheet.readRows) {
+				row = childSheet.readRows[i]
+			}
+			axis, _ := childSheet.axis(row, childJoin.Col)
+			errs = append(errs, &CellError{
+				Sheet:  child.sheet,
+				Row:    row,
+				Col:    childJoin.Col,
+				Header: childJoin.headerName,
+				Err:    errors.Errorf("%s表格(%s)在%s中不存在", axis, key, parent.sheet),
+			})
+			continue
+		}
+		field := item.Field(target)
+		field.Set(reflect.Append(field, children.Index(i)))
+	}
+	return errs, nil
+}
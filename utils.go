@@ -8,3 +8,10 @@ func getElem(elem reflect.Value) reflect.Value {
 	}
 	return elem
 }
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}